@@ -1,69 +1,253 @@
 package cmd
 
 import (
+	"context"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/yusufcanb/kubot/pkg/app"
+	"github.com/yusufcanb/kubot/pkg/suite"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 )
 
 var execCmd = &cobra.Command{
-	Use:   "exec",
-	Short: "Execute a job",
+	Use:     "exec [flags] [-- robot arguments]",
+	Short:   "Execute a job",
+	Example: `  kubot exec --workspace scripts --image ppodgorsek/robot-framework -- --variable ENV:staging --loglevel DEBUG`,
 	Run: func(cmd *cobra.Command, args []string) {
+		// arguments after -- are passed through to robot
+		var robotArgs []string
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			robotArgs = args[dash:]
+			args = args[:dash]
+		}
+		if len(args) > 0 {
+			exitWithError(app.ExitCodeInvalidArgs, "unexpected arguments %v, robot arguments are given after --", args)
+		}
+
 		name, err := cmd.Flags().GetString("name")
 		if err != nil || name == "" {
-			log.Fatalf("Error getting name flag: %s", err)
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting name flag: %s", err)
 		}
 
+		// the image is only required by the kubernetes backend, it is validated by the app
 		image, err := cmd.Flags().GetString("image")
-		if err != nil || image == "" {
-			log.Fatalf("Error getting image flag: %s", err)
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting image flag: %s", err)
 		}
 
 		batchSize, err := cmd.Flags().GetInt("batchsize")
 		if err != nil || batchSize == 0 {
-			log.Fatalf("Error getting batchsize flag: %s", err)
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting batchsize flag: %s", err)
 		}
 
 		namespace, err := cmd.Flags().GetString("namespace")
-		if err != nil || namespace == "" {
-			log.Fatalf("Error getting namespace flag: %s", err)
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting namespace flag: %s", err)
 		}
 
 		workspace, err := cmd.Flags().GetString("workspace")
 		if err != nil || workspace == "" {
-			log.Fatalf("Error getting workspace flag: %s", err)
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting workspace flag: %s", err)
+		}
+
+		selectors, err := cmd.Flags().GetStringArray("selector")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting selector flag: %s", err)
+		}
+
+		excludes, err := cmd.Flags().GetStringArray("exclude")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting exclude flag: %s", err)
+		}
+
+		rerunFailed, err := cmd.Flags().GetInt("rerun-failed")
+		if err != nil || rerunFailed < 0 {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting rerun-failed flag: %s", err)
+		}
+
+		testLevelSplit, err := cmd.Flags().GetInt("testlevelsplit")
+		if err != nil || testLevelSplit < 0 {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting testlevelsplit flag: %s", err)
+		}
+
+		history, err := cmd.Flags().GetString("history")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting history flag: %s", err)
+		}
+
+		suiteTimeout, err := cmd.Flags().GetDuration("suite-timeout")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting suite-timeout flag: %s", err)
+		}
+
+		var timeouts []app.TimeoutRule
+		if err := viper.UnmarshalKey("timeouts", &timeouts); err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error reading timeouts of the config: %s", err)
+		}
+
+		var resourceProfiles map[string]suite.ResourceProfile
+		if err := viper.UnmarshalKey("resources.profiles", &resourceProfiles); err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error reading resource profiles of the config: %s", err)
+		}
+
+		var resourceRules []app.ProfileRule
+		if err := viper.UnmarshalKey("resources.rules", &resourceRules); err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error reading resource rules of the config: %s", err)
+		}
+
+		envVars, err := cmd.Flags().GetStringArray("env")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting env flag: %s", err)
+		}
+
+		envFromHost, err := cmd.Flags().GetStringArray("env-from-host")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting env-from-host flag: %s", err)
+		}
+
+		envFromSecrets, err := cmd.Flags().GetStringArray("env-from-secret")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting env-from-secret flag: %s", err)
+		}
+
+		envFromConfigMaps, err := cmd.Flags().GetStringArray("env-from-configmap")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting env-from-configmap flag: %s", err)
+		}
+
+		rebotArgs, err := cmd.Flags().GetStringArray("rebot-arg")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting rebot-arg flag: %s", err)
+		}
+
+		backoffLimit, err := cmd.Flags().GetInt("backoff-limit")
+		if err != nil || backoffLimit < 0 {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting backoff-limit flag: %s", err)
+		}
+
+		activeDeadline, err := cmd.Flags().GetDuration("active-deadline")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting active-deadline flag: %s", err)
+		}
+
+		ttlAfterFinished, err := cmd.Flags().GetDuration("ttl-after-finished")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting ttl-after-finished flag: %s", err)
+		}
+
+		backend, err := cmd.Flags().GetString("backend")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting backend flag: %s", err)
+		}
+
+		transfer, err := cmd.Flags().GetString("transfer")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting transfer flag: %s", err)
+		}
+
+		failOnSkipped, err := cmd.Flags().GetBool("fail-on-skipped")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting fail-on-skipped flag: %s", err)
+		}
+
+		installDependencies, err := cmd.Flags().GetBool("install-dependencies")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting install-dependencies flag: %s", err)
 		}
 
-		selector, err := cmd.Flags().GetString("selector")
-		if err != nil || selector == "" {
-			selector = workspace
+		quiet, err := cmd.Flags().GetBool("quiet")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting quiet flag: %s", err)
 		}
 
-		k, err := app.New(app.RuntimeArgs{
-			TopLevelSuiteName: name,
-			Namespace:         namespace,
-			Image:             image,
-			WorkspacePath:     workspace,
-			Selector:          selector,
-			BatchSize:         batchSize,
+		verbose, err := cmd.Flags().GetBool("verbose")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting verbose flag: %s", err)
+		}
+		progress, err := cmd.Flags().GetString("progress")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting progress flag: %s", err)
+		}
+
+		if quiet && verbose {
+			exitWithError(app.ExitCodeInvalidArgs, "quiet and verbose flags cannot be used together")
+		}
+		if verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		// the first interrupt cancels the execution and cleans up, a second one kills the process
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-ctx.Done()
+			stop()
+			log.Warn("Interrupted, cleaning up created resources. Interrupt again to force quit.")
+		}()
+
+		k, err := app.New(ctx, app.RuntimeArgs{
+			Backend:      backend,
+			Transfer:     transfer,
+			StorageClass: viper.GetString("volume.storageClass"),
+			StorageSize:  viper.GetString("volume.size"),
+			AccessModes:  viper.GetStringSlice("volume.accessModes"),
+			PodTemplate:  viper.GetString("podTemplate"),
+			Env: suite.EnvOptions{
+				Vars:       envVars,
+				FromHost:   envFromHost,
+				Secrets:    envFromSecrets,
+				ConfigMaps: envFromConfigMaps,
+			},
+			RobotArgs:           robotArgs,
+			RebotArgs:           rebotArgs,
+			InstallDependencies: installDependencies,
+			ResourceProfiles:    resourceProfiles,
+			ResourceProfile:     viper.GetString("resources.profile"),
+			ResourceRules:       resourceRules,
+			TopLevelSuiteName:   name,
+			Cluster:             clusterOptions(namespace),
+			Image:               image,
+			WorkspacePath:       workspace,
+			Selectors:           selectors,
+			Excludes:            excludes,
+			BatchSize:           batchSize,
+			RerunFailed:         rerunFailed,
+			TestLevelSplit:      testLevelSplit,
+			HistoryPath:         history,
+			SuiteTimeout:        suiteTimeout,
+			Timeouts:            timeouts,
+			FailOnSkipped:       failOnSkipped,
+			Quiet:               quiet,
+			Progress:            progress,
+			BackoffLimit:        backoffLimit,
+			ActiveDeadline:      activeDeadline,
+			TTLAfterFinished:    ttlAfterFinished,
 		})
 
 		if err != nil {
-			log.Fatal(err)
+			exitWithError(app.ExitCodeOf(err), "%s", err)
 		}
 
-		err = k.Run()
+		err = k.Run(ctx)
 		if err != nil {
-			log.Fatal(err)
+			log.Error(err)
 		}
 
-		defer k.Clean()
+		k.Clean()
+		os.Exit(k.ExitCode(err))
 	},
 }
 
+// exitWithError logs the error and exits with the given code
+func exitWithError(code int, format string, args ...interface{}) {
+	log.Errorf(format, args...)
+	os.Exit(code)
+}
+
 func init() {
 	ex, err := os.Executable()
 	if err != nil {
@@ -72,10 +256,42 @@ func init() {
 
 	execCmd.Flags().StringP("workspace", "w", filepath.Dir(ex), "workspace path")
 	execCmd.Flags().StringP("name", "n", "Kubot Results", "top level suite name for logs and reports")
-	execCmd.Flags().StringP("namespace", "", "", "kubernetes namespace to create workloads in it")
-	execCmd.Flags().StringP("image", "i", "", "docker image for execution for pods and jobs")
-	execCmd.Flags().IntP("batchsize", "b", 25, "execution batch size")
-	execCmd.Flags().StringP("selector", "s", "", "script selector. e.g. tasks/*")
+	execCmd.Flags().StringP("namespace", "", "", "kubernetes namespace to create workloads in it (default namespace of the kubeconfig context)")
+	execCmd.Flags().StringP("image", "i", "", "docker image for execution for pods and jobs, required by the kubernetes backend")
+	execCmd.Flags().IntP("batchsize", "b", 25, "maximum number of suites running concurrently")
+	execCmd.Flags().StringArrayP("selector", "s", nil, "script selector to include, repeatable. e.g. tasks/*, **/smoke_*.robot, re:^login_, tag:smoke")
+	execCmd.Flags().StringArrayP("exclude", "x", nil, "script selector to exclude, repeatable. same syntax as --selector")
+	execCmd.Flags().IntP("rerun-failed", "", 0, "number of times to rerun the failed tests of a suite")
+	execCmd.Flags().IntP("testlevelsplit", "", 0, "split suites with more tests than the given number into groups of tests running in parallel (default suites are not split)")
+	execCmd.Flags().StringP("history", "", filepath.Join(suite.LocalOutputDir, "history.json"), "file keeping the suite durations of the previous runs to start the longest suites first. empty disables it")
+	execCmd.Flags().DurationP("suite-timeout", "", 0, "maximum duration of a suite execution. robot is stopped to write the results so far, then killed after a grace period. e.g. 30m (default no timeout)")
+	execCmd.Flags().BoolP("fail-on-skipped", "", false, "count skipped tests as failed in the exit code, including non-critical tests skipped with --skiponfailure")
+	execCmd.Flags().BoolP("install-dependencies", "", false, "install the requirements.txt or pyproject.toml of the workspace into a virtualenv before the suites run, needs the volume transfer")
+	execCmd.Flags().BoolP("quiet", "q", false, "do not stream the robot console of the suites, they are still saved next to their outputs")
+	execCmd.Flags().BoolP("verbose", "v", false, "log debug messages and stream the output of the commands executed in the pods")
+	execCmd.Flags().StringP("progress", "", app.ProgressAuto, "progress display while the suites are running. auto redraws a view on a terminal and logs status lines otherwise, plain always logs status lines, none disables it")
+	execCmd.Flags().StringArrayP("env", "e", nil, "environment variable of the robot container as KEY=VALUE, repeatable. the value is passed as is")
+	execCmd.Flags().StringArrayP("env-from-host", "", nil, "glob pattern of the environment variables of this machine to pass to the robot container, repeatable. e.g. CI_*")
+	execCmd.Flags().StringArrayP("env-from-secret", "", nil, "secret whose keys become environment variables of the robot container, repeatable")
+	execCmd.Flags().StringArrayP("env-from-configmap", "", nil, "config map whose keys become environment variables of the robot container, repeatable")
+	execCmd.Flags().StringArrayP("rebot-arg", "", nil, "argument passed through to rebot merging the results, repeatable. e.g. --rebot-arg=--removekeywords=passed")
+	execCmd.Flags().IntP("backoff-limit", "", 2, "number of retries of a suite job on infrastructure failures. failed tests are never retried")
+	execCmd.Flags().DurationP("active-deadline", "", 0, "maximum duration of a suite job including retries. e.g. 30m (default no deadline)")
+	execCmd.Flags().DurationP("ttl-after-finished", "", 10*time.Minute, "duration to keep finished suite jobs before they are deleted. negative values keep them")
+
+	execCmd.Flags().StringP("backend", "", suite.BackendKubernetes, "where the suites are executed. kubernetes runs them as jobs, local runs robot processes on this machine")
+	execCmd.Flags().StringP("transfer", "", suite.TransferVolume, "how the workspace and results are transferred. volume shares a persistent volume claim, stream ships the workspace in a config map and streams the results back")
+	execCmd.Flags().StringP("storage-class", "", "", "storage class of the suite volume (default cluster default storage class)")
+	execCmd.Flags().StringP("storage-size", "", "1Gi", "size of the suite volume")
+	execCmd.Flags().StringP("pod-template", "", "", "YAML file of a partial pod spec merged into the pods, e.g. node selectors, tolerations or the service account")
+	execCmd.Flags().StringP("resource-profile", "", "", "resource profile of the config file used by the suites no resource rule matches (default the default profile)")
+	execCmd.Flags().StringSliceP("access-mode", "", []string{"ReadWriteOnce"}, "access modes of the suite volume. e.g. ReadWriteMany or RWX")
+
+	_ = viper.BindPFlag("volume.storageClass", execCmd.Flags().Lookup("storage-class"))
+	_ = viper.BindPFlag("volume.size", execCmd.Flags().Lookup("storage-size"))
+	_ = viper.BindPFlag("volume.accessModes", execCmd.Flags().Lookup("access-mode"))
+	_ = viper.BindPFlag("podTemplate", execCmd.Flags().Lookup("pod-template"))
+	_ = viper.BindPFlag("resources.profile", execCmd.Flags().Lookup("resource-profile"))
 
 	rootCmd.AddCommand(execCmd)
 }
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/yusufcanb/kubot/pkg/app"
+	"github.com/yusufcanb/kubot/pkg/cluster"
+	"github.com/yusufcanb/kubot/pkg/gc"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Delete resources left behind by finished or stale runs",
+	Run: func(cmd *cobra.Command, args []string) {
+		namespace, err := cmd.Flags().GetString("namespace")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting namespace flag: %s", err)
+		}
+
+		allNamespaces, err := cmd.Flags().GetBool("all-namespaces")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting all-namespaces flag: %s", err)
+		}
+
+		runID, err := cmd.Flags().GetString("run-id")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting run-id flag: %s", err)
+		}
+
+		olderThan, err := cmd.Flags().GetDuration("older-than")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting older-than flag: %s", err)
+		}
+
+		includeRunning, err := cmd.Flags().GetBool("include-running")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting include-running flag: %s", err)
+		}
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			exitWithError(app.ExitCodeInvalidArgs, "Error getting dry-run flag: %s", err)
+		}
+
+		c, err := cluster.NewCluster(clusterOptions(namespace))
+		if err != nil {
+			exitWithError(app.ExitCodeInfrastructure, "%s", err)
+		}
+
+		collector := gc.NewCollector(c)
+		runs, err := collector.List(context.Background(), gc.Options{
+			AllNamespaces:  allNamespaces,
+			RunID:          runID,
+			OlderThan:      olderThan,
+			IncludeRunning: includeRunning,
+		})
+		if err != nil {
+			exitWithError(app.ExitCodeInfrastructure, "%s", err)
+		}
+
+		if len(runs) == 0 {
+			fmt.Println("No resources to collect.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RUN ID\tNAMESPACE\tKIND\tNAME\tAGE")
+		for _, run := range runs {
+			for _, r := range run.Resources {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", run.ID, r.Namespace, r.Kind, r.Name, time.Since(r.CreatedAt).Round(time.Second))
+			}
+		}
+		w.Flush()
+
+		if dryRun {
+			fmt.Printf("%d runs would be deleted (dry run).\n", len(runs))
+			return
+		}
+
+		err = collector.Delete(context.Background(), runs)
+		if err != nil {
+			exitWithError(app.ExitCodeInfrastructure, "%s", err)
+		}
+
+		fmt.Printf("%d runs deleted.\n", len(runs))
+	},
+}
+
+func init() {
+	gcCmd.Flags().StringP("namespace", "", "", "kubernetes namespace to collect resources in (default namespace of the kubeconfig context)")
+	gcCmd.Flags().BoolP("all-namespaces", "A", false, "collect resources in every namespace")
+	gcCmd.Flags().StringP("run-id", "", "", "only collect the resources of the given run")
+	gcCmd.Flags().DurationP("older-than", "", 10*time.Minute, "only collect runs without any resource created within the duration")
+	gcCmd.Flags().BoolP("include-running", "", false, "collect runs with running suites or merges as well, e.g. stuck runs")
+	gcCmd.Flags().BoolP("dry-run", "", false, "only list the resources which would be deleted")
+
+	rootCmd.AddCommand(gcCmd)
+}
@@ -22,6 +22,7 @@ package cmd
 
 import (
 	"github.com/yusufcanb/kubot/pkg/app"
+	"github.com/yusufcanb/kubot/pkg/cluster"
 	"os"
 	"path"
 
@@ -32,6 +33,13 @@ import (
 
 var cfgFile string
 
+var (
+	kubeConfig   string
+	kubeContext  string
+	kubeAPIQPS   float32
+	kubeAPIBurst int
+)
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:     "kubot",
@@ -47,7 +55,7 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(app.ExitCodeInvalidArgs)
 	}
 }
 
@@ -58,6 +66,21 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.roc-cli.yaml)")
+	rootCmd.PersistentFlags().StringVar(&kubeConfig, "kubeconfig", "", "path to the kubeconfig file (default KUBECONFIG or $HOME/.kube/config, in-cluster config when none exists)")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", "", "kubeconfig context to use (default current context)")
+	rootCmd.PersistentFlags().Float32Var(&kubeAPIQPS, "kube-api-qps", 20, "maximum queries per second to the kubernetes api")
+	rootCmd.PersistentFlags().IntVar(&kubeAPIBurst, "kube-api-burst", 40, "maximum burst of queries to the kubernetes api")
+}
+
+// clusterOptions returns the options to connect to the cluster given by the root flags
+func clusterOptions(namespace string) cluster.Options {
+	return cluster.Options{
+		KubeConfig: kubeConfig,
+		Context:    kubeContext,
+		Namespace:  namespace,
+		QPS:        kubeAPIQPS,
+		Burst:      kubeAPIBurst,
+	}
 }
 
 // initConfig reads in config file and ENV variables if set.
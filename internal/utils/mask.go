@@ -0,0 +1,83 @@
+package utils
+
+import (
+	log "github.com/sirupsen/logrus"
+	"io"
+	"sort"
+	"strings"
+)
+
+// maskedValue replaces the masked values in the logs
+const maskedValue = "***"
+
+// minMaskedLength keeps short values, e.g. 1 or true, from masking unrelated text
+const minMaskedLength = 4
+
+// MaskHook replaces the given values in the messages and fields of every log entry
+type MaskHook struct {
+	replacer *strings.Replacer
+}
+
+func (it *MaskHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (it *MaskHook) Fire(entry *log.Entry) error {
+	entry.Message = it.replacer.Replace(entry.Message)
+	for key, value := range entry.Data {
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = it.replacer.Replace(v)
+		case error:
+			entry.Data[key] = it.replacer.Replace(v.Error())
+		}
+	}
+
+	return nil
+}
+
+// Mask returns the text with the values replaced
+func (it *MaskHook) Mask(text string) string {
+	return it.replacer.Replace(text)
+}
+
+// Writer returns a writer masking the values written into w, a value split across two writes is not masked
+// so every write should hold whole lines
+func (it *MaskHook) Writer(w io.Writer) io.Writer {
+	return &maskWriter{hook: it, w: w}
+}
+
+type maskWriter struct {
+	hook *MaskHook
+	w    io.Writer
+}
+
+func (it *maskWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(it.w, it.hook.Mask(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// NewMaskHook creates a hook masking the values, nil when none of them is long enough to be masked
+func NewMaskHook(values []string) *MaskHook {
+	masked := make([]string, 0, len(values))
+	for _, value := range values {
+		if len(value) >= minMaskedLength {
+			masked = append(masked, value)
+		}
+	}
+	if len(masked) == 0 {
+		return nil
+	}
+
+	// longer values first, so a value containing another one is masked as a whole
+	sort.Slice(masked, func(i, j int) bool { return len(masked[i]) > len(masked[j]) })
+
+	pairs := make([]string, 0, len(masked)*2)
+	for _, value := range masked {
+		pairs = append(pairs, value, maskedValue)
+	}
+
+	return &MaskHook{replacer: strings.NewReplacer(pairs...)}
+}
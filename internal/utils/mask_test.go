@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"testing"
+)
+
+func TestMaskHook(t *testing.T) {
+	if hook := NewMaskHook([]string{"1", "yes"}); hook != nil {
+		t.Errorf("NewMaskHook() got = %v, want nil for short values", hook)
+	}
+
+	hook := NewMaskHook([]string{"s3cr3t", "s3cr3t-token", "on"})
+
+	buf := &bytes.Buffer{}
+	logger := log.New()
+	logger.SetOutput(buf)
+	logger.AddHook(hook)
+
+	logger.WithError(errors.New("login with s3cr3t failed")).Errorf("token s3cr3t-token is on")
+	logger.Info("running on prod at port 8080 with headless true")
+
+	got := buf.String()
+	if strings.Contains(got, "s3cr3t") || !strings.Contains(got, "token *** is on") {
+		t.Errorf("log got = %q, want the values masked", got)
+	}
+	if !strings.Contains(got, "running on prod at port 8080 with headless true") {
+		t.Errorf("log got = %q, want the values not given to the hook visible", got)
+	}
+
+	buf.Reset()
+	_, _ = hook.Writer(buf).Write([]byte("job-1 >>> [robot --variable TOKEN:s3cr3t-token]\n"))
+	if got := buf.String(); got != "job-1 >>> [robot --variable TOKEN:***]\n" {
+		t.Errorf("Writer() got = %q, want the values masked", got)
+	}
+}
@@ -15,7 +15,7 @@ func ArchiveWorkspace(path *string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to create temporary file: %v", err)
 	}
-	//defer os.Remove(tempFile.Name()) // Remove the temp file when we're done
+	defer tempFile.Close() // the caller removes the archive when it is done
 
 	// Create a gzip writer for the temporary file
 	gzipWriter := gzip.NewWriter(tempFile)
@@ -68,7 +68,7 @@ func ArchiveWorkspace(path *string) (string, error) {
 
 	filePath, err := GetAbsolutePath(tempFile.Name())
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 
 	return filePath, nil
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"errors"
+	"github.com/yusufcanb/kubot/pkg/result"
+)
+
+// Exit codes are compatible with the robot return codes. Up to 250 failed tests are reported
+// as their count, the codes from 252 on are used for errors of kubot itself.
+const (
+	ExitCodeSuccess        = 0
+	ExitCodeMaxFailures    = 250
+	ExitCodeInvalidArgs    = 252 // invalid options or no suites to execute
+	ExitCodeInterrupted    = 253 // execution stopped by the user
+	ExitCodeInfrastructure = 254 // suites could not be executed or results could not be collected
+	ExitCodeInternal       = 255 // unexpected internal error
+)
+
+// ExitError associates an error with the exit code of the process
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// ExitCodeOf returns the exit code for the given error, errors without an exit code are infrastructure errors
+func ExitCodeOf(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return ExitCodeInfrastructure
+}
+
+// exitCodeOfOutput returns the number of failed tests capped at ExitCodeMaxFailures
+func exitCodeOfOutput(output *result.Output, failOnSkipped bool) int {
+	stats := output.Suite.Statistics()
+
+	failed := stats.Failed
+	if failOnSkipped {
+		failed += stats.Skipped
+	}
+
+	if failed > ExitCodeMaxFailures {
+		return ExitCodeMaxFailures
+	}
+
+	return failed
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"github.com/yusufcanb/kubot/pkg/result"
+	"testing"
+)
+
+func outputWith(passed int, failed int, skipped int) *result.Output {
+	output := &result.Output{}
+	for i := 0; i < passed; i++ {
+		output.Suite.Tests = append(output.Suite.Tests, result.Test{Status: result.Status{Status: result.StatusPass}})
+	}
+	for i := 0; i < failed; i++ {
+		output.Suite.Tests = append(output.Suite.Tests, result.Test{Status: result.Status{Status: result.StatusFail}})
+	}
+	for i := 0; i < skipped; i++ {
+		output.Suite.Tests = append(output.Suite.Tests, result.Test{Status: result.Status{Status: result.StatusSkip}})
+	}
+	return output
+}
+
+func TestExitCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "no error", err: nil, want: ExitCodeSuccess},
+		{name: "invalid args", err: withExitCode(ExitCodeInvalidArgs, errors.New("no suites matched the selectors")), want: ExitCodeInvalidArgs},
+		{name: "wrapped", err: fmt.Errorf("exec: %w", withExitCode(ExitCodeInterrupted, errors.New("interrupted"))), want: ExitCodeInterrupted},
+		{name: "infrastructure", err: errors.New("job failed: BackoffLimitExceeded"), want: ExitCodeInfrastructure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCodeOf(tt.err); got != tt.want {
+				t.Errorf("ExitCodeOf() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCodeOfOutput(t *testing.T) {
+	tests := []struct {
+		name          string
+		output        *result.Output
+		failOnSkipped bool
+		want          int
+	}{
+		{name: "all passed", output: outputWith(10, 0, 0), want: 0},
+		{name: "failed", output: outputWith(10, 3, 2), want: 3},
+		{name: "fail on skipped", output: outputWith(10, 3, 2), failOnSkipped: true, want: 5},
+		{name: "capped", output: outputWith(0, 300, 0), want: ExitCodeMaxFailures},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCodeOfOutput(tt.output, tt.failOnSkipped); got != tt.want {
+				t.Errorf("exitCodeOfOutput() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
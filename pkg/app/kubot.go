@@ -1,26 +1,41 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/yusufcanb/kubot/pkg/batch"
 	"github.com/yusufcanb/kubot/pkg/cluster"
 	"github.com/yusufcanb/kubot/pkg/suite"
 	"github.com/yusufcanb/kubot/pkg/workspace"
+	"time"
 )
 
 type App struct {
-	cluster *cluster.Cluster
+	cluster *cluster.Cluster // nil with the local backend
+	runID   string
 
 	workspace *workspace.Workspace
+	shards    []batch.Shard // selected suites or groups of their tests to execute
 
-	suiteVolume *suite.Volume // volume to extract workspace into
+	transfer    suite.Transfer // ships the workspace into the suite pods, nil with the local backend
 	suiteRunner *suite.Runner
 
+	history     *batch.History // durations of the previous runs, nil when disabled
+	historyPath string
+
 	topLevelSuiteName string
 	batchSize         int
+	failOnSkipped     bool
 }
 
-func (it *App) Run() error {
-	err := it.suiteRunner.Run(it.workspace, it.suiteVolume, it.batchSize)
+func (it *App) Run(ctx context.Context) error {
+	err := it.suiteRunner.Run(ctx, it.shards, it.batchSize)
+	it.saveHistory()
+
+	if ctx.Err() != nil {
+		return withExitCode(ExitCodeInterrupted, fmt.Errorf("execution interrupted: %w", ctx.Err()))
+	}
 	if err != nil {
 		return err
 	}
@@ -28,9 +43,54 @@ func (it *App) Run() error {
 	return nil
 }
 
+// saveHistory records the durations of the executed suites, suites which have not run keep their previous durations
+func (it *App) saveHistory() {
+	if it.history == nil {
+		return
+	}
+
+	now := time.Now()
+	for name, duration := range it.suiteRunner.Durations() {
+		it.history.Record(name, duration, now)
+	}
+
+	if err := it.history.Save(it.historyPath); err != nil {
+		log.Warnf("failed to save the history: %s", err)
+	}
+}
+
+// RunID returns the identifier of the run, every created resource is labelled with it
+func (it *App) RunID() string {
+	return it.runID
+}
+
+// ExitCode returns the exit code of the process for the result of Run.
+// Errors take precedence over the number of failed tests.
+func (it *App) ExitCode(runErr error) int {
+	if runErr != nil {
+		return ExitCodeOf(runErr)
+	}
+
+	output := it.suiteRunner.Output()
+	if output == nil {
+		return ExitCodeInfrastructure
+	}
+
+	return exitCodeOfOutput(output, it.failOnSkipped)
+}
+
+// Clean deletes the resources created by the app. It is not bound to the execution
+// context, so resources are deleted after an interruption as well.
 func (it *App) Clean() {
-	err := it.suiteVolume.Destroy()
+	if it.transfer == nil {
+		return
+	}
+
+	ctx, cancel := suite.CleanupContext()
+	defer cancel()
+
+	err := it.transfer.Destroy(ctx)
 	if err != nil {
-		log.Fatal(err)
+		log.Errorf("cleanup failed: %s", err)
 	}
 }
@@ -1,13 +1,171 @@
 package app
 
 import (
+	"context"
+	"github.com/yusufcanb/kubot/pkg/batch"
+	"github.com/yusufcanb/kubot/pkg/cluster/fake"
+	"github.com/yusufcanb/kubot/pkg/suite"
+	"github.com/yusufcanb/kubot/pkg/workspace"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
-func TestKubot_Init(t *testing.T) {
+const mergedOutput = `<?xml version="1.0" encoding="UTF-8"?>
+<robot generator="Rebot 6.1.1 (Python 3.11.4 on linux)" generated="20230801 10:00:05.000" rpa="false" schemaversion="4">
+<suite id="s1" name="Kubot Results">
+<suite id="s1-s1" name="Scripts" source="/data/workspace/scripts">
+<suite id="s1-s1-s1" name="Google" source="/data/workspace/scripts/google.robot">
+<test id="s1-s1-s1-t1" name="Search">
+<status status="PASS" starttime="20230801 10:00:01.000" endtime="20230801 10:00:02.000"/>
+</test>
+<test id="s1-s1-s1-t2" name="Visit">
+<status status="FAIL" starttime="20230801 10:00:02.000" endtime="20230801 10:00:03.000">Timeout</status>
+</test>
+<status status="FAIL" starttime="20230801 10:00:00.000" endtime="20230801 10:00:04.000"/>
+</suite>
+<status status="FAIL" starttime="20230801 10:00:00.000" endtime="20230801 10:00:04.000"/>
+</suite>
+<status status="FAIL" starttime="N/A" endtime="N/A"/>
+</suite>
+</robot>
+`
 
+const suiteOutput = `<?xml version="1.0" encoding="UTF-8"?>
+<robot generator="Robot 6.1.1 (Python 3.11.4 on linux)" generated="20230801 10:00:05.000" rpa="false" schemaversion="4">
+<suite id="s1" name="Scripts" source="/data/workspace/scripts">
+<status status="PASS" starttime="20230801 10:00:00.000" endtime="20230801 10:01:30.000"/>
+</suite>
+</robot>
+`
+
+// newTestApp creates an app running the given suites on a fake cluster in a temporary working directory
+func newTestApp(t *testing.T, ctx context.Context, suites ...string) (*App, *fake.Executor) {
+	t.Helper()
+
+	root := filepath.Join(t.TempDir(), "scripts")
+	for _, name := range suites {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte("*** Test Cases ***\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	c, client, executor := fake.NewCluster("kubot")
+	fake.FinishJobs(client, batchv1.JobComplete, "")
+
+	w, err := workspace.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := suite.NewVolume(ctx, c, "run-1", suite.VolumeOptions{Size: "1Gi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.InitDirectories(ctx, w); err != nil {
+		t.Fatal(err)
+	}
+
+	return &App{
+		cluster:           c,
+		runID:             "run-1",
+		workspace:         w,
+		shards:            batch.SuiteShards(w.Suites()),
+		transfer:          v,
+		suiteRunner:       suite.NewRunner(suite.NewKubernetesExecutor(v, "robot", suite.JobOptions{}), "Kubot Results", 0, nil),
+		topLevelSuiteName: "Kubot Results",
+		batchSize:         2,
+	}, executor
 }
 
 func TestKubot_Run(t *testing.T) {
+	ctx := context.Background()
+	app, executor := newTestApp(t, ctx, "google.robot", "admin/users.robot")
+	executor.Files["/data/output/output.xml"] = mergedOutput
+
+	err := app.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := app.ExitCode(err); code != 1 {
+		t.Errorf("ExitCode() got = %v, want 1 failed test", code)
+	}
+
+	executed := executor.Executed()
+	rebot := executed[len(executed)-1]
+	if !strings.HasPrefix(rebot, "rebot --merge") ||
+		!strings.Contains(rebot, " /data/output/admin/users.robot/output.xml") ||
+		!strings.Contains(rebot, " /data/output/google.robot/output.xml") {
+		t.Errorf("merge command got = %v", rebot)
+	}
+
+	jobs, _ := app.cluster.Client().BatchV1().Jobs("kubot").List(ctx, metav1.ListOptions{})
+	if len(jobs.Items) != 2 {
+		t.Errorf("jobs got = %d, want a job per suite", len(jobs.Items))
+	}
+
+	app.Clean()
+	claims, _ := app.cluster.Client().CoreV1().PersistentVolumeClaims("kubot").List(ctx, metav1.ListOptions{})
+	if len(claims.Items) != 0 {
+		t.Errorf("claims got = %d after Clean(), want 0", len(claims.Items))
+	}
+}
+
+func TestKubot_RunSavesHistory(t *testing.T) {
+	ctx := context.Background()
+	app, executor := newTestApp(t, ctx, "google.robot", "admin/users.robot")
+	executor.Files["/data/output/output.xml"] = mergedOutput
+	executor.Files["/data/output/google.robot/output.xml"] = suiteOutput
+
+	app.historyPath = filepath.Join(suite.LocalOutputDir, "history.json")
+	app.history = &batch.History{Suites: map[string]batch.HistoryEntry{"admin/users.robot": {Seconds: 30}}}
+
+	if err := app.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := batch.LoadHistory(app.historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := history.Duration("google.robot"); got != 90*time.Second {
+		t.Errorf("Duration() got = %v, want the elapsed time of the suite output", got)
+	}
+	if got, _ := history.Duration("admin/users.robot"); got != 30*time.Second {
+		t.Errorf("Duration() got = %v, want the previous duration of a suite without output", got)
+	}
+}
+
+func TestKubot_RunInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	app, _ := newTestApp(t, ctx, "google.robot")
+	cancel()
+
+	err := app.Run(ctx)
+	if code := app.ExitCode(err); code != ExitCodeInterrupted {
+		t.Errorf("ExitCode() got = %v, want %v", code, ExitCodeInterrupted)
+	}
 
+	app.Clean()
+	claims, _ := app.cluster.Client().CoreV1().PersistentVolumeClaims("kubot").List(context.Background(), metav1.ListOptions{})
+	if len(claims.Items) != 0 {
+		t.Errorf("claims got = %d after Clean(), want 0", len(claims.Items))
+	}
 }
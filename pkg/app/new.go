@@ -1,39 +1,283 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/yusufcanb/kubot/internal/utils"
+	"github.com/yusufcanb/kubot/pkg/batch"
 	"github.com/yusufcanb/kubot/pkg/cluster"
+	"github.com/yusufcanb/kubot/pkg/progress"
 	"github.com/yusufcanb/kubot/pkg/suite"
 	"github.com/yusufcanb/kubot/pkg/workspace"
+	"io"
+	"os"
+	"strings"
+	"time"
 )
 
-func New(args RuntimeArgs) (*App, error) {
+// New prepares the workspace and ships it into the cluster with the kubernetes backend.
+// The created resources are deleted when it fails.
+func New(ctx context.Context, args RuntimeArgs) (*App, error) {
 	var err error
 	var app = App{}
 
 	app.topLevelSuiteName = args.TopLevelSuiteName
 	app.batchSize = args.BatchSize
+	app.failOnSkipped = args.FailOnSkipped
 
-	app.cluster, err = cluster.NewCluster("", args.Namespace)
+	app.workspace, err = workspace.New(args.WorkspacePath)
 	if err != nil {
-		return nil, err
+		return nil, withExitCode(ExitCodeInvalidArgs, err)
 	}
 
-	app.workspace, err = workspace.New(args.WorkspacePath)
+	selector, err := workspace.NewSelector(args.Selectors, args.Excludes)
+	if err != nil {
+		return nil, withExitCode(ExitCodeInvalidArgs, err)
+	}
+
+	suites, err := selector.Select(app.workspace)
+	if err != nil {
+		return nil, withExitCode(ExitCodeInvalidArgs, err)
+	}
+
+	if len(suites) == 0 {
+		return nil, withExitCode(ExitCodeInvalidArgs, errors.New("no suites matched the selectors"))
+	}
+
+	app.shards, err = batch.SplitTests(app.workspace.Root().Path, suites, args.TestLevelSplit)
+	if err != nil {
+		return nil, withExitCode(ExitCodeInvalidArgs, err)
+	}
+	if len(app.shards) > len(suites) {
+		log.Infof("split %d suites into %d shards", len(suites), len(app.shards))
+	}
+
+	err = applyTimeouts(app.workspace.Root().Path, app.shards, args.SuiteTimeout, args.Timeouts)
 	if err != nil {
-		return nil, err
+		return nil, withExitCode(ExitCodeInvalidArgs, err)
+	}
+
+	if args.HistoryPath != "" {
+		app.history, err = batch.LoadHistory(args.HistoryPath)
+		if err != nil {
+			return nil, withExitCode(ExitCodeInvalidArgs, err)
+		}
+		app.historyPath = args.HistoryPath
+		app.shards = app.history.Order(app.shards)
+	}
+
+	if err := suite.ValidateRobotArgs(args.RobotArgs); err != nil {
+		return nil, withExitCode(ExitCodeInvalidArgs, err)
+	}
+	if err := suite.ValidateRebotArgs(args.RebotArgs); err != nil {
+		return nil, withExitCode(ExitCodeInvalidArgs, err)
 	}
 
-	app.suiteVolume, err = suite.NewVolume(app.cluster)
+	env, err := suite.NewEnv(args.Env, os.Environ())
 	if err != nil {
-		return nil, err
+		return nil, withExitCode(ExitCodeInvalidArgs, err)
+	}
+	// credentials may be taken from the host
+	hook := utils.NewMaskHook(env.SecretValues())
+	if hook != nil {
+		log.AddHook(hook)
+		suite.SetEcho(hook.Writer(os.Stdout))
 	}
 
-	err = app.suiteVolume.InitDirectories(app.workspace)
+	var deps *suite.Dependencies
+	if args.InstallDependencies {
+		deps, err = suite.DetectDependencies(app.workspace.Root().Path)
+		if err != nil {
+			return nil, withExitCode(ExitCodeInvalidArgs, err)
+		}
+		if deps == nil {
+			return nil, withExitCode(ExitCodeInvalidArgs, fmt.Errorf("the workspace root has no %s or %s to install", suite.RequirementsFile, suite.PyprojectFile))
+		}
+		// every suite pod would install them from the package index, the stream transfer has no shared storage
+		if args.Backend == suite.BackendKubernetes && args.Transfer == suite.TransferStream {
+			return nil, withExitCode(ExitCodeInvalidArgs, fmt.Errorf("installing dependencies needs --transfer=%s, the %s transfer has no storage shared by the suite pods", suite.TransferVolume, suite.TransferStream))
+		}
+		log.Infof("the dependencies of %s are installed before the suites run", deps.File())
+	}
+
+	app.runID = suite.NewRunID()
+	log.Infof("starting run %s", app.runID)
+
+	view, err := app.newProgressView(args)
 	if err != nil {
-		return nil, err
+		return nil, withExitCode(ExitCodeInvalidArgs, err)
+	}
+
+	var executor suite.Executor
+	switch args.Backend {
+	case suite.BackendKubernetes:
+		err = app.initKubernetes(ctx, args, env, deps)
+		if err != nil {
+			return nil, err
+		}
+
+		executor = suite.NewKubernetesExecutor(app.transfer, args.Image, suite.JobOptions{
+			BackoffLimit:            int32(args.BackoffLimit),
+			ActiveDeadlineSeconds:   int64(args.ActiveDeadline.Seconds()),
+			TTLSecondsAfterFinished: int32(args.TTLAfterFinished.Seconds()),
+		})
+	case suite.BackendLocal:
+		if args.PodTemplate != "" || args.ResourceProfile != "" {
+			log.Warn("the pod template and the resource profiles are ignored by the local backend")
+		}
+		if len(env.Secrets()) > 0 || len(env.ConfigMaps()) > 0 {
+			return nil, withExitCode(ExitCodeInvalidArgs, errors.New("secrets and config maps are only supported by the kubernetes backend"))
+		}
+		localExecutor, err := suite.NewLocalExecutor(app.workspace, env)
+		if err != nil {
+			return nil, withExitCode(ExitCodeInvalidArgs, err)
+		}
+		err = localExecutor.InstallDependencies(ctx, deps)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, withExitCode(ExitCodeInterrupted, err)
+			}
+			return nil, err
+		}
+		executor = localExecutor
+	default:
+		return nil, withExitCode(ExitCodeInvalidArgs, fmt.Errorf("invalid backend %q, use %s or %s", args.Backend, suite.BackendKubernetes, suite.BackendLocal))
+	}
+
+	// the consoles are printed above the interactive view
+	var console io.Writer = os.Stdout
+	if view != nil {
+		console = view
+	}
+	if hook != nil {
+		console = hook.Writer(console)
+	}
+	if args.Quiet {
+		console = nil
 	}
 
-	app.suiteRunner = suite.NewRunner(app.cluster, args.Image, app.topLevelSuiteName)
+	app.suiteRunner = suite.NewRunner(executor, app.topLevelSuiteName, args.RerunFailed, console)
+	app.suiteRunner.SetProgress(view)
+	app.suiteRunner.SetArgs(args.RobotArgs, args.RebotArgs)
 
 	return &app, nil
 }
+
+// newProgressView creates the progress view of the progress mode, nil when it is disabled
+func (it *App) newProgressView(args RuntimeArgs) (*progress.View, error) {
+	var estimates map[string]time.Duration
+	if it.history != nil {
+		estimates = it.history.Durations()
+	}
+	tracker := progress.NewTracker(args.BatchSize, estimates)
+
+	switch args.Progress {
+	case ProgressAuto:
+		return progress.NewView(tracker, os.Stdout, progress.IsTerminal(os.Stdout)), nil
+	case ProgressPlain:
+		return progress.NewView(tracker, os.Stdout, false), nil
+	case ProgressNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid progress mode %q, use %s, %s or %s", args.Progress, ProgressAuto, ProgressPlain, ProgressNone)
+	}
+}
+
+// podOptions loads the pod template and the resource profiles, and picks the resource profile of every shard
+func (it *App) podOptions(args RuntimeArgs, env *suite.Env, deps *suite.Dependencies) (suite.PodOptions, error) {
+	options := suite.PodOptions{Env: env, Dependencies: deps}
+	var err error
+
+	for _, variable := range os.Environ() {
+		if strings.HasPrefix(variable, "KUBOT_POD_") {
+			log.Warnf("%s is no longer supported, define the pod resources in a resource profile", strings.SplitN(variable, "=", 2)[0])
+		}
+	}
+
+	if args.PodTemplate != "" {
+		options.Template, err = suite.LoadPodTemplate(args.PodTemplate)
+		if err != nil {
+			return options, err
+		}
+	}
+
+	options.Resources, err = suite.NewResourceProfiles(args.ResourceProfiles)
+	if err != nil {
+		return options, err
+	}
+
+	err = applyProfiles(it.workspace.Root().Path, it.shards, options.Resources, args.ResourceProfile, args.ResourceRules)
+	if err != nil {
+		return options, err
+	}
+
+	return options, nil
+}
+
+// initKubernetes connects to the cluster and ships the workspace with the transfer mode of the args
+func (it *App) initKubernetes(ctx context.Context, args RuntimeArgs, env *suite.Env, deps *suite.Dependencies) error {
+	var err error
+
+	if args.Image == "" {
+		return withExitCode(ExitCodeInvalidArgs, errors.New("an image is required with the kubernetes backend"))
+	}
+
+	pods, err := it.podOptions(args, env, deps)
+	if err != nil {
+		return withExitCode(ExitCodeInvalidArgs, err)
+	}
+
+	it.cluster, err = cluster.NewCluster(args.Cluster)
+	if err != nil {
+		return err
+	}
+
+	err = env.Preflight(ctx, it.cluster)
+	if err != nil {
+		return withExitCode(ExitCodeInvalidArgs, err)
+	}
+
+	switch args.Transfer {
+	case suite.TransferVolume:
+		volumeOptions := suite.VolumeOptions{
+			StorageClass: args.StorageClass,
+			Size:         args.StorageSize,
+			AccessModes:  args.AccessModes,
+			Pods:         pods,
+		}
+
+		err = suite.Preflight(ctx, it.cluster, volumeOptions, args.BatchSize)
+		if err != nil {
+			return withExitCode(ExitCodeInvalidArgs, err)
+		}
+
+		suiteVolume, err := suite.NewVolume(ctx, it.cluster, it.runID, volumeOptions)
+		if err != nil {
+			if ctx.Err() != nil {
+				return withExitCode(ExitCodeInterrupted, err)
+			}
+			return err
+		}
+		it.transfer = suiteVolume
+	case suite.TransferStream:
+		it.transfer = suite.NewStream(it.cluster, it.runID, pods)
+	default:
+		return withExitCode(ExitCodeInvalidArgs, fmt.Errorf("invalid transfer mode %q, use %s or %s", args.Transfer, suite.TransferVolume, suite.TransferStream))
+	}
+
+	err = it.transfer.InitDirectories(ctx, it.workspace)
+	if err == nil {
+		err = it.transfer.InstallDependencies(ctx, args.Image)
+	}
+	if err != nil {
+		it.Clean()
+		if ctx.Err() != nil {
+			return withExitCode(ExitCodeInterrupted, err)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"fmt"
+	"github.com/yusufcanb/kubot/pkg/batch"
+	"github.com/yusufcanb/kubot/pkg/suite"
+)
+
+// ProfileRule selects the resource profile of the suites matching the selector, e.g. {"selector": "ui/**", "profile": "browser-heavy"}
+type ProfileRule struct {
+	Selector string `mapstructure:"selector"` // same syntax as --selector
+	Profile  string `mapstructure:"profile"`
+}
+
+// applyProfiles sets the resource profile of every shard from the first rule matching its suite, the default profile
+// otherwise. Every referenced profile must be defined.
+func applyProfiles(root string, shards []batch.Shard, profiles *suite.ResourceProfiles, defaultProfile string, rules []ProfileRule) error {
+	if defaultProfile != "" && !profiles.Has(defaultProfile) {
+		return fmt.Errorf("unknown resource profile %q", defaultProfile)
+	}
+
+	expressions := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if !profiles.Has(rule.Profile) {
+			return fmt.Errorf("invalid resource rule, unknown resource profile %q: %+v", rule.Profile, rule)
+		}
+		expressions = append(expressions, rule.Selector)
+	}
+
+	selectors, err := ruleSelectors(expressions)
+	if err != nil {
+		return fmt.Errorf("invalid resource rule: %s", err)
+	}
+
+	for i := range shards {
+		rule, err := firstMatch(selectors, root, shards[i].Suite)
+		if err != nil {
+			return fmt.Errorf("resource profile of %s", err)
+		}
+
+		shards[i].Profile = defaultProfile
+		if rule >= 0 {
+			shards[i].Profile = rules[rule].Profile
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"github.com/yusufcanb/kubot/pkg/batch"
+	"github.com/yusufcanb/kubot/pkg/suite"
+	"testing"
+)
+
+func TestApplyProfiles(t *testing.T) {
+	profiles, err := suite.NewResourceProfiles(map[string]suite.ResourceProfile{
+		"small":         {Limits: map[string]string{"cpu": "250m"}},
+		"browser-heavy": {Limits: map[string]string{"cpu": "2"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := []ProfileRule{{Selector: "ui/**", Profile: "browser-heavy"}}
+
+	shards := []batch.Shard{{Name: "ui/checkout.robot", Suite: "ui/checkout.robot"}, {Name: "api/users.robot", Suite: "api/users.robot"}}
+	if err := applyProfiles(t.TempDir(), shards, profiles, "small", rules); err != nil {
+		t.Fatal(err)
+	}
+	if shards[0].Profile != "browser-heavy" || shards[1].Profile != "small" {
+		t.Errorf("applyProfiles() got = %+v, want the rule profile and the default profile", shards)
+	}
+
+	if err := applyProfiles(t.TempDir(), shards, profiles, "large", nil); err == nil {
+		t.Error("applyProfiles() error = nil, want an unknown default profile")
+	}
+	if err := applyProfiles(t.TempDir(), shards, profiles, "", []ProfileRule{{Selector: "ui/**", Profile: "gpu"}}); err == nil {
+		t.Error("applyProfiles() error = nil, want an unknown rule profile")
+	}
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"fmt"
+	"github.com/yusufcanb/kubot/pkg/workspace"
+)
+
+// ruleSelectors parses the selectors of the rules of the config file, a rule applies to the suites its selector matches
+func ruleSelectors(expressions []string) ([]*workspace.Selector, error) {
+	selectors := make([]*workspace.Selector, 0, len(expressions))
+	for _, expression := range expressions {
+		if expression == "" {
+			return nil, fmt.Errorf("a selector is required")
+		}
+
+		selector, err := workspace.NewSelector([]string{expression}, nil)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+	}
+
+	return selectors, nil
+}
+
+// firstMatch returns the index of the first selector matching the suite, -1 when none matches
+func firstMatch(selectors []*workspace.Selector, root string, suite string) (int, error) {
+	for i, selector := range selectors {
+		matched, err := selector.Match(root, suite)
+		if err != nil {
+			return -1, fmt.Errorf("match %s: %s", suite, err)
+		}
+		if matched {
+			return i, nil
+		}
+	}
+
+	return -1, nil
+}
@@ -1,10 +1,54 @@
 package app
 
+import (
+	"github.com/yusufcanb/kubot/pkg/cluster"
+	"github.com/yusufcanb/kubot/pkg/suite"
+	"time"
+)
+
+const (
+	ProgressAuto  = "auto"  // interactive view on a terminal, status lines otherwise
+	ProgressPlain = "plain" // status lines
+	ProgressNone  = "none"
+)
+
 type RuntimeArgs struct {
+	Cluster cluster.Options // connection to the cluster, including the namespace to create the workloads in
+
 	TopLevelSuiteName string
-	Namespace         string
 	Image             string
-	Selector          string
+	Selectors         []string
+	Excludes          []string
 	WorkspacePath     string
 	BatchSize         int
+	RerunFailed       int
+	TestLevelSplit    int           // maximum number of tests per shard, suites are not split when 0
+	HistoryPath       string        // file keeping the suite durations to schedule the longest first, disabled when empty
+	SuiteTimeout      time.Duration // time a suite may run for before robot is stopped, no limit when 0
+	Timeouts          []TimeoutRule // per suite overrides of the suite timeout, the first matching rule wins
+	FailOnSkipped     bool
+	Quiet             bool   // the suite consoles are only saved, not streamed into the terminal
+	Progress          string // auto, plain or none
+
+	Backend      string // kubernetes or local
+	Transfer     string // volume or stream
+	StorageClass string
+	StorageSize  string
+	AccessModes  []string
+	PodTemplate  string // file of the partial pod spec merged into the pods, none when empty
+
+	Env suite.EnvOptions // environment variables of the robot container
+
+	RobotArgs []string // passed through to robot, e.g. --variable or --loglevel
+	RebotArgs []string // passed through to rebot merging the results
+
+	InstallDependencies bool // requirements.txt or pyproject.toml of the workspace is installed before the suites run
+
+	ResourceProfiles map[string]suite.ResourceProfile // named resources of the robot container
+	ResourceProfile  string                           // profile of the suites no rule matches, the default profile when empty
+	ResourceRules    []ProfileRule                    // profiles of the suites, the first matching rule wins
+
+	BackoffLimit     int
+	ActiveDeadline   time.Duration
+	TTLAfterFinished time.Duration
 }
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"fmt"
+	"github.com/yusufcanb/kubot/pkg/batch"
+	"time"
+)
+
+// TimeoutRule overrides the suite timeout of the suites matching the selector, e.g. {"selector": "ui/**", "timeout": "45m"}
+type TimeoutRule struct {
+	Selector string        `mapstructure:"selector"` // same syntax as --selector
+	Timeout  time.Duration `mapstructure:"timeout"`  // 0 disables the timeout of the matching suites
+}
+
+// applyTimeouts sets the timeout of every shard from the first rule matching its suite, the default timeout otherwise.
+// Groups of tests of a suite share the timeout of the suite.
+func applyTimeouts(root string, shards []batch.Shard, defaultTimeout time.Duration, rules []TimeoutRule) error {
+	if defaultTimeout < 0 {
+		return fmt.Errorf("invalid suite timeout %s", defaultTimeout)
+	}
+
+	expressions := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Timeout < 0 {
+			return fmt.Errorf("invalid timeout rule, the timeout cannot be negative: %+v", rule)
+		}
+		expressions = append(expressions, rule.Selector)
+	}
+
+	selectors, err := ruleSelectors(expressions)
+	if err != nil {
+		return fmt.Errorf("invalid timeout rule: %s", err)
+	}
+
+	for i := range shards {
+		rule, err := firstMatch(selectors, root, shards[i].Suite)
+		if err != nil {
+			return fmt.Errorf("timeout of %s", err)
+		}
+
+		shards[i].Timeout = defaultTimeout
+		if rule >= 0 {
+			shards[i].Timeout = rules[rule].Timeout
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"github.com/yusufcanb/kubot/pkg/batch"
+	"testing"
+	"time"
+)
+
+func TestApplyTimeouts(t *testing.T) {
+	rules := []TimeoutRule{
+		{Selector: "ui/**", Timeout: 45 * time.Minute},
+		{Selector: "ui/checkout.robot", Timeout: time.Hour},
+		{Selector: "re:_slow", Timeout: 0},
+	}
+
+	tests := []struct {
+		name  string
+		suite string
+		want  time.Duration
+	}{
+		{name: "default", suite: "api/users.robot", want: 10 * time.Minute},
+		{name: "first match wins", suite: "ui/checkout.robot", want: 45 * time.Minute},
+		{name: "disabled", suite: "api/export_slow.robot", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shards := []batch.Shard{{Name: tt.suite + "/group-1", Suite: tt.suite}}
+			if err := applyTimeouts(t.TempDir(), shards, 10*time.Minute, rules); err != nil {
+				t.Fatal(err)
+			}
+			if got := shards[0].Timeout; got != tt.want {
+				t.Errorf("applyTimeouts() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTimeouts_InvalidRule(t *testing.T) {
+	shards := []batch.Shard{{Name: "ui/login.robot", Suite: "ui/login.robot"}}
+	if err := applyTimeouts(t.TempDir(), shards, 0, []TimeoutRule{{Selector: "re:(", Timeout: time.Minute}}); err == nil {
+		t.Error("applyTimeouts() error = nil, want an invalid selector")
+	}
+	if err := applyTimeouts(t.TempDir(), shards, 0, []TimeoutRule{{Timeout: time.Minute}}); err == nil {
+		t.Error("applyTimeouts() error = nil, want a missing selector")
+	}
+}
@@ -0,0 +1,97 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// HistoryEntry is the last known duration of a shard
+type HistoryEntry struct {
+	Seconds   float64   `json:"seconds"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// History keeps the durations of the shards executed by the previous runs by their names
+type History struct {
+	Suites map[string]HistoryEntry `json:"suites"`
+}
+
+// Duration returns the last known duration of the shard
+func (it *History) Duration(name string) (time.Duration, bool) {
+	entry, ok := it.Suites[name]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(entry.Seconds * float64(time.Second)), true
+}
+
+// Durations returns the last known durations by shard name
+func (it *History) Durations() map[string]time.Duration {
+	durations := make(map[string]time.Duration, len(it.Suites))
+	for name := range it.Suites {
+		durations[name], _ = it.Duration(name)
+	}
+	return durations
+}
+
+// Record saves the duration of the shard, replacing the previous one
+func (it *History) Record(name string, duration time.Duration, at time.Time) {
+	it.Suites[name] = HistoryEntry{Seconds: duration.Seconds(), UpdatedAt: at}
+}
+
+// Order sorts the shards longest first, so the longest ones never start last. Shards without a known
+// duration may be the longest ones, they are kept first in their original order.
+func (it *History) Order(shards []Shard) []Shard {
+	ordered := append([]Shard{}, shards...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		di, knownI := it.Duration(ordered[i].Name)
+		dj, knownJ := it.Duration(ordered[j].Name)
+		if knownI != knownJ {
+			return !knownI
+		}
+		return di > dj
+	})
+	return ordered
+}
+
+// Save writes the history into the given file
+func (it *History) Save(path string) error {
+	content, err := json.MarshalIndent(it, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0644)
+}
+
+// LoadHistory reads the history from the given file, the history is empty when the file does not exist
+func LoadHistory(path string) (*History, error) {
+	history := &History{Suites: make(map[string]HistoryEntry)}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, history); err != nil {
+		return nil, fmt.Errorf("invalid history file %s: %v", path, err)
+	}
+	if history.Suites == nil {
+		history.Suites = make(map[string]HistoryEntry)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,52 @@
+package batch
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistory_Order(t *testing.T) {
+	history := &History{Suites: make(map[string]HistoryEntry)}
+	history.Record("short.robot", 10*time.Second, time.Now())
+	history.Record("long.robot", 5*time.Minute, time.Now())
+	history.Record("medium.robot", time.Minute, time.Now())
+
+	shards := SuiteShards([]string{"short.robot", "new.robot", "long.robot", "medium.robot", "other.robot"})
+
+	var got []string
+	for _, shard := range history.Order(shards) {
+		got = append(got, shard.Name)
+	}
+
+	want := []string{"new.robot", "other.robot", "long.robot", "medium.robot", "short.robot"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Order() got = %v, want %v", got, want)
+	}
+	if shards[0].Name != "short.robot" {
+		t.Errorf("Order() modified the given shards: %v", shards)
+	}
+}
+
+func TestHistory_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".kubot", "history.json")
+
+	history, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v, want an empty history for a missing file", err)
+	}
+
+	history.Record("google.robot", 90*time.Second, time.Now())
+	if err := history.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := loaded.Duration("google.robot"); !ok || got != 90*time.Second {
+		t.Errorf("Duration() got = %v, %v, want %v", got, ok, 90*time.Second)
+	}
+}
@@ -0,0 +1,81 @@
+package batch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Scheduler keeps up to concurrency suites running at all times.
+// Unlike a fixed batch, the next suite in the queue is started as soon as
+// any running suite finishes, so one slow suite never holds the free slots.
+type Scheduler struct {
+	concurrency int
+
+	mu        sync.Mutex
+	listeners []func(Event)
+}
+
+// OnEvent registers a listener to be notified on every suite start and finish.
+// Listeners are called sequentially, never concurrently.
+func (it *Scheduler) OnEvent(listener func(Event)) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	it.listeners = append(it.listeners, listener)
+}
+
+func (it *Scheduler) emit(e Event) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	for _, listener := range it.listeners {
+		listener(e)
+	}
+}
+
+// Run executes every suite in the queue and blocks until all of them are finished.
+// When the context is cancelled no more suites are started, running suites are awaited.
+func (it *Scheduler) Run(ctx context.Context, suites []string, execute func(suite string) error) {
+	workers := it.concurrency
+	if workers > len(suites) {
+		workers = len(suites)
+	}
+
+	queue := make(chan string)
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for suite := range queue {
+				it.emit(Event{Type: SuiteStarted, Suite: suite, Time: time.Now()})
+				err := execute(suite)
+				it.emit(Event{Type: SuiteFinished, Suite: suite, Time: time.Now(), Err: err})
+			}
+		}()
+	}
+
+dispatch:
+	for _, suite := range suites {
+		select {
+		case queue <- suite:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+
+	wg.Wait()
+}
+
+func NewScheduler(concurrency int) *Scheduler {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Scheduler{
+		concurrency: concurrency,
+	}
+}
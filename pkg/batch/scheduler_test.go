@@ -0,0 +1,120 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler_RunRespectsConcurrency(t *testing.T) {
+	var suites []string
+	for i := 0; i < 20; i++ {
+		suites = append(suites, fmt.Sprintf("suite-%d.robot", i))
+	}
+
+	var running, peak int32
+	s := NewScheduler(4)
+	s.Run(context.Background(), suites, func(suite string) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+
+	if peak != 4 {
+		t.Errorf("Run() peak concurrency = %v, want %v", peak, 4)
+	}
+}
+
+func TestScheduler_RunDoesNotWaitForSlowSuite(t *testing.T) {
+	s := NewScheduler(2)
+
+	var mu sync.Mutex
+	var finished []string
+	s.OnEvent(func(e Event) {
+		if e.Type == SuiteFinished {
+			mu.Lock()
+			finished = append(finished, e.Suite)
+			mu.Unlock()
+		}
+	})
+
+	s.Run(context.Background(), []string{"slow.robot", "a.robot", "b.robot", "c.robot"}, func(suite string) error {
+		if suite == "slow.robot" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return nil
+	})
+
+	if len(finished) != 4 {
+		t.Fatalf("Run() finished %v suites, want %v", len(finished), 4)
+	}
+	if finished[3] != "slow.robot" {
+		t.Errorf("Run() finish order = %v, want slow.robot to finish last", finished)
+	}
+}
+
+func TestScheduler_RunEmitsEvents(t *testing.T) {
+	s := NewScheduler(1)
+
+	var events []Event
+	s.OnEvent(func(e Event) {
+		events = append(events, e)
+	})
+
+	failure := errors.New("robot script failed")
+	s.Run(context.Background(), []string{"pass.robot", "fail.robot"}, func(suite string) error {
+		if suite == "fail.robot" {
+			return failure
+		}
+		return nil
+	})
+
+	want := []struct {
+		typ   EventType
+		suite string
+		err   error
+	}{
+		{SuiteStarted, "pass.robot", nil},
+		{SuiteFinished, "pass.robot", nil},
+		{SuiteStarted, "fail.robot", nil},
+		{SuiteFinished, "fail.robot", failure},
+	}
+
+	if len(events) != len(want) {
+		t.Fatalf("Run() emitted %v events, want %v", len(events), len(want))
+	}
+	for i, w := range want {
+		if events[i].Type != w.typ || events[i].Suite != w.suite || events[i].Err != w.err {
+			t.Errorf("event[%d] = %v %v %v, want %v %v %v", i, events[i].Type, events[i].Suite, events[i].Err, w.typ, w.suite, w.err)
+		}
+	}
+}
+
+func TestScheduler_RunStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewScheduler(2)
+
+	var started int32
+	s.Run(ctx, []string{"a.robot", "b.robot", "c.robot", "d.robot", "e.robot"}, func(suite string) error {
+		if atomic.AddInt32(&started, 1) == 2 {
+			cancel()
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	if started > 3 {
+		t.Errorf("Run() started %v suites after cancel, want at most %v", started, 3)
+	}
+}
@@ -0,0 +1,65 @@
+package batch
+
+import (
+	"fmt"
+	"github.com/yusufcanb/kubot/pkg/workspace"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Shard is a unit of execution, a whole suite file or a group of its tests
+type Shard struct {
+	Name  string   // unique name of the shard, the suite path for whole suites, e.g. admin/users.robot/group-2 for groups
+	Suite string   // path of the suite file relative to the workspace root
+	Tests []string // names of the tests of the group, empty for whole suites
+
+	Timeout time.Duration // time the shard may run for before it is stopped, zero for no limit
+	Profile string        // resource profile of the shard pods, the default profile of the run when empty
+}
+
+// SuiteShards returns a shard for every suite file
+func SuiteShards(suites []string) []Shard {
+	shards := make([]Shard, 0, len(suites))
+	for _, suite := range suites {
+		shards = append(shards, Shard{Name: suite, Suite: suite})
+	}
+	return shards
+}
+
+// SplitTests splits the suites having more than testsPerShard tests into evenly sized groups of at most
+// testsPerShard tests, keeping the order they are defined in. Suite files are read from the workspace root.
+func SplitTests(root string, suites []string, testsPerShard int) ([]Shard, error) {
+	if testsPerShard < 1 {
+		return SuiteShards(suites), nil
+	}
+
+	shards := make([]Shard, 0, len(suites))
+	for _, suite := range suites {
+		suiteFile, err := workspace.ParseSuiteFile(filepath.Join(root, filepath.FromSlash(suite)))
+		if err != nil {
+			return nil, fmt.Errorf("split %s: %s", suite, err)
+		}
+
+		tests := make([]string, 0, len(suiteFile.Tests))
+		for _, test := range suiteFile.Tests {
+			tests = append(tests, test.Name)
+		}
+
+		if len(tests) <= testsPerShard {
+			shards = append(shards, Shard{Name: suite, Suite: suite})
+			continue
+		}
+
+		groups := (len(tests) + testsPerShard - 1) / testsPerShard
+		for i := 0; i < groups; i++ {
+			shards = append(shards, Shard{
+				Name:  path.Join(suite, fmt.Sprintf("group-%d", i+1)),
+				Suite: suite,
+				Tests: tests[i*len(tests)/groups : (i+1)*len(tests)/groups],
+			})
+		}
+	}
+
+	return shards, nil
+}
@@ -0,0 +1,87 @@
+package batch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeSuite(t *testing.T, root string, name string, tests ...string) {
+	t.Helper()
+
+	content := "*** Test Cases ***\n"
+	for _, test := range tests {
+		content += test + "\n    Log    " + test + "\n"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSplitTests(t *testing.T) {
+	root := t.TempDir()
+	writeSuite(t, root, "google.robot", "Search", "Visit")
+	writeSuite(t, root, "admin/users.robot", "Create", "Update", "Delete", "List", "Find")
+
+	tests := []struct {
+		name          string
+		testsPerShard int
+		want          []Shard
+	}{
+		{
+			name:          "disabled",
+			testsPerShard: 0,
+			want: []Shard{
+				{Name: "google.robot", Suite: "google.robot"},
+				{Name: "admin/users.robot", Suite: "admin/users.robot"},
+			},
+		},
+		{
+			name:          "evenly sized groups",
+			testsPerShard: 2,
+			want: []Shard{
+				{Name: "google.robot", Suite: "google.robot"},
+				{Name: "admin/users.robot/group-1", Suite: "admin/users.robot", Tests: []string{"Create"}},
+				{Name: "admin/users.robot/group-2", Suite: "admin/users.robot", Tests: []string{"Update", "Delete"}},
+				{Name: "admin/users.robot/group-3", Suite: "admin/users.robot", Tests: []string{"List", "Find"}},
+			},
+		},
+		{
+			name:          "a test per group",
+			testsPerShard: 1,
+			want: []Shard{
+				{Name: "google.robot/group-1", Suite: "google.robot", Tests: []string{"Search"}},
+				{Name: "google.robot/group-2", Suite: "google.robot", Tests: []string{"Visit"}},
+				{Name: "admin/users.robot/group-1", Suite: "admin/users.robot", Tests: []string{"Create"}},
+				{Name: "admin/users.robot/group-2", Suite: "admin/users.robot", Tests: []string{"Update"}},
+				{Name: "admin/users.robot/group-3", Suite: "admin/users.robot", Tests: []string{"Delete"}},
+				{Name: "admin/users.robot/group-4", Suite: "admin/users.robot", Tests: []string{"List"}},
+				{Name: "admin/users.robot/group-5", Suite: "admin/users.robot", Tests: []string{"Find"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitTests(root, []string{"google.robot", "admin/users.robot"}, tt.testsPerShard)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitTests() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTests_MissingSuite(t *testing.T) {
+	_, err := SplitTests(t.TempDir(), []string{"missing.robot"}, 2)
+	if err == nil || !strings.Contains(err.Error(), "missing.robot") {
+		t.Errorf("SplitTests() error = %v, want the missing suite", err)
+	}
+}
@@ -1,40 +1,31 @@
 package batch
 
-import "github.com/yusufcanb/kubot/pkg/workspace"
-
-// Batch struct represents a batch of files to be processed.
-// It contains a size field which represents the size of each batch,
-// and a cursor field which represents the current position in the file list.
-type Batch struct {
-	size   int
-	cursor int
-
-	workspace *workspace.Workspace
-}
-
-func (b *Batch) Next() []string {
-	allFiles := b.workspace.Root().Files
-	if b.cursor >= len(allFiles) {
-		return nil
+import "time"
+
+// EventType represents the kind of event emitted by the Scheduler.
+type EventType int
+
+const (
+	SuiteStarted EventType = iota
+	SuiteFinished
+)
+
+func (t EventType) String() string {
+	switch t {
+	case SuiteStarted:
+		return "started"
+	case SuiteFinished:
+		return "finished"
+	default:
+		return "unknown"
 	}
-
-	var batchSize int
-	if b.cursor+b.size > len(allFiles) {
-		batchSize = len(allFiles) - b.cursor
-	} else {
-		batchSize = b.size
-	}
-
-	batch := allFiles[b.cursor : b.cursor+batchSize]
-	b.cursor += batchSize
-
-	return batch
 }
 
-func NewBatch(size int, w *workspace.Workspace) *Batch {
-	return &Batch{
-		size:      size,
-		cursor:    0,
-		workspace: w,
-	}
+// Event struct represents a state change of a scheduled suite.
+// Err is only set on SuiteFinished events of failed suites.
+type Event struct {
+	Type  EventType
+	Suite string
+	Time  time.Time
+	Err   error
 }
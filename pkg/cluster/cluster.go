@@ -2,24 +2,39 @@ package cluster
 
 import (
 	"fmt"
+	log "github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"os"
+	"strings"
 )
 
+// inClusterNamespacePath is the namespace of the service account mounted into pods
+const inClusterNamespacePath = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// Options configures the connection to the cluster
+type Options struct {
+	KubeConfig string  // kubeconfig path, KUBECONFIG or ~/.kube/config is used when empty
+	Context    string  // current context of the kubeconfig is used when empty
+	Namespace  string  // namespace of the context is used when empty
+	QPS        float32 // client-go default is used when 0
+	Burst      int     // client-go default is used when 0
+}
+
 type Cluster struct {
 	defaultNamespace string
 
-	client *kubernetes.Clientset
-	config *rest.Config
+	client   kubernetes.Interface
+	config   *rest.Config
+	executor Executor
 }
 
 func (c *Cluster) DefaultNamespace() string {
 	return c.defaultNamespace
 }
 
-func (c *Cluster) Client() *kubernetes.Clientset {
+func (c *Cluster) Client() kubernetes.Interface {
 	return c.client
 }
 
@@ -27,20 +42,83 @@ func (c *Cluster) Config() *rest.Config {
 	return c.config
 }
 
-func NewCluster(kubeConfigPath string, namespace string) (*Cluster, error) {
-	cluster := Cluster{}
-	cluster.defaultNamespace = namespace
+// Executor returns the executor running commands and copies in the pods of the cluster
+func (c *Cluster) Executor() Executor {
+	return c.executor
+}
+
+// loadConfig loads the client config and the namespace of the selected context. The kubeconfig files are
+// merged like kubectl does, the in-cluster config is used when there is no kubeconfig at all.
+func loadConfig(options Options) (*rest.Config, string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = options.KubeConfig
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: options.Context}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+
+	rawConfig, err := clientConfig.RawConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if options.KubeConfig == "" && options.Context == "" && len(rawConfig.Contexts) == 0 {
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, "", fmt.Errorf("no kubeconfig is found and kubot is not running inside a cluster: %v", err)
+		}
+		log.Debug("using in-cluster config")
+
+		return config, inClusterNamespace(), nil
+	}
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return config, namespace, nil
+}
+
+// inClusterNamespace returns the namespace of the pod kubot runs in
+func inClusterNamespace() string {
+	namespace, err := os.ReadFile(inClusterNamespacePath)
+	if err != nil || strings.TrimSpace(string(namespace)) == "" {
+		return "default"
+	}
+	return strings.TrimSpace(string(namespace))
+}
 
-	if home, _ := os.UserHomeDir(); home != "" {
-		kubeConfigPath = fmt.Sprintf("%s/.kube/config", home)
+// New creates a cluster with the given client and executor, e.g. fakes in tests
+func New(client kubernetes.Interface, executor Executor, namespace string) *Cluster {
+	return &Cluster{
+		defaultNamespace: namespace,
+		client:           client,
+		executor:         executor,
 	}
+}
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+func NewCluster(options Options) (*Cluster, error) {
+	config, namespace, err := loadConfig(options)
 	if err != nil {
 		return nil, err
 	}
 
-	cluster.config = config
+	if options.QPS > 0 {
+		config.QPS = options.QPS
+	}
+	if options.Burst > 0 {
+		config.Burst = options.Burst
+	}
+
+	if options.Namespace != "" {
+		namespace = options.Namespace
+	}
+	log.Debugf("using %s in namespace %s", config.Host, namespace)
 
 	// create the client
 	client, err := kubernetes.NewForConfig(config)
@@ -48,7 +126,8 @@ func NewCluster(kubeConfigPath string, namespace string) (*Cluster, error) {
 		return nil, err
 	}
 
-	cluster.client = client
+	cluster := New(client, &remoteExecutor{client: client, config: config}, namespace)
+	cluster.config = config
 
-	return &cluster, nil
+	return cluster, nil
 }
@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const kubeConfigTemplate = `apiVersion: v1
+kind: Config
+clusters:
+- name: %[1]s
+  cluster:
+    server: https://%[1]s.example.com
+contexts:
+- name: %[1]s
+  context:
+    cluster: %[1]s
+    user: %[1]s
+    namespace: %[2]s
+current-context: %[1]s
+users:
+- name: %[1]s
+  user:
+    token: secret
+`
+
+func writeKubeConfig(t *testing.T, name string, namespace string) string {
+	t.Helper()
+	kubeConfigPath := filepath.Join(t.TempDir(), name)
+	content := []byte(fmt.Sprintf(kubeConfigTemplate, name, namespace))
+	if err := os.WriteFile(kubeConfigPath, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return kubeConfigPath
+}
+
+func TestNewCluster(t *testing.T) {
+	staging := writeKubeConfig(t, "staging", "robots")
+	production := writeKubeConfig(t, "production", "")
+
+	tests := []struct {
+		name          string
+		kubeConfigEnv string
+		options       Options
+		wantHost      string
+		wantNamespace string
+		wantErr       bool
+	}{
+		{
+			name:          "explicit kubeconfig",
+			options:       Options{KubeConfig: staging},
+			wantHost:      "https://staging.example.com",
+			wantNamespace: "robots",
+		},
+		{
+			name:          "namespace flag overrides the context",
+			options:       Options{KubeConfig: staging, Namespace: "kubot"},
+			wantHost:      "https://staging.example.com",
+			wantNamespace: "kubot",
+		},
+		{
+			name:          "merged KUBECONFIG paths",
+			kubeConfigEnv: production + string(os.PathListSeparator) + staging,
+			options:       Options{Context: "staging"},
+			wantHost:      "https://staging.example.com",
+			wantNamespace: "robots",
+		},
+		{
+			name:          "first KUBECONFIG path sets the current context",
+			kubeConfigEnv: production + string(os.PathListSeparator) + staging,
+			wantHost:      "https://production.example.com",
+			wantNamespace: "default",
+		},
+		{
+			name:    "unknown context",
+			options: Options{KubeConfig: staging, Context: "development"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KUBECONFIG", tt.kubeConfigEnv)
+
+			c, err := NewCluster(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewCluster() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if c.Config().Host != tt.wantHost {
+				t.Errorf("NewCluster() host got = %v, want %v", c.Config().Host, tt.wantHost)
+			}
+			if c.DefaultNamespace() != tt.wantNamespace {
+				t.Errorf("NewCluster() namespace got = %v, want %v", c.DefaultNamespace(), tt.wantNamespace)
+			}
+		})
+	}
+}
+
+func TestNewCluster_QPS(t *testing.T) {
+	c, err := NewCluster(Options{KubeConfig: writeKubeConfig(t, "staging", ""), QPS: 50, Burst: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Config().QPS != 50 || c.Config().Burst != 100 {
+		t.Errorf("NewCluster() qps got = %v/%v, want 50/100", c.Config().QPS, c.Config().Burst)
+	}
+}
@@ -0,0 +1,269 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Streams are attached to a command executed in a container, nil streams are not attached
+type Streams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer // ignored with TTY, the terminal output is written into Stdout
+	TTY    bool
+}
+
+// Executor executes commands in containers and copies archives from and into them
+type Executor interface {
+	// Exec executes the command in a container of the pod
+	Exec(ctx context.Context, pod *corev1.Pod, container string, cmd []string, streams Streams) error
+	// Upload copies a local tar or tar.gz archive into the container and extracts it into remoteDir
+	Upload(ctx context.Context, pod *corev1.Pod, container string, archivePath string, remoteDir string) error
+	// Download archives the given files under remoteDir of the container into the local tar archive at localPath
+	Download(ctx context.Context, pod *corev1.Pod, container string, remoteDir string, names []string, localPath string) error
+}
+
+// progressInterval is the interval between the progress logs of a copy
+var progressInterval = 5 * time.Second
+
+// progress logs the number of bytes written into it periodically
+type progress struct {
+	name        string
+	total       int64 // 0 when unknown
+	transferred int64
+	reportedAt  time.Time
+}
+
+func (it *progress) Write(p []byte) (int, error) {
+	it.transferred += int64(len(p))
+	if time.Since(it.reportedAt) >= progressInterval {
+		it.report()
+	}
+	return len(p), nil
+}
+
+func (it *progress) report() {
+	it.reportedAt = time.Now()
+	if it.total > 0 {
+		log.Infof("%s: %s of %s (%d%%)", it.name, formatBytes(it.transferred), formatBytes(it.total), it.transferred*100/it.total)
+		return
+	}
+	log.Infof("%s: %s", it.name, formatBytes(it.transferred))
+}
+
+func newProgress(name string, total int64) *progress {
+	// the first report is logged after an interval, short copies are not reported at all
+	return &progress{name: name, total: total, reportedAt: time.Now()}
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. 1.5 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// remoteTempPath returns a unique path for a temporary archive inside a pod
+func remoteTempPath(ext string) string {
+	return path.Join("/tmp", fmt.Sprintf("kubot-%s%s", rand.String(8), ext))
+}
+
+// shellQuote quotes the value for sh, remote paths are named after the suites and may hold any character
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// checksumCommand prints the checksum and the size of the remote file, see parseChecksum
+func checksumCommand(remotePath string) string {
+	return fmt.Sprintf("sha256sum < %s && wc -c < %s", shellQuote(remotePath), shellQuote(remotePath))
+}
+
+// extractCommand extracts the remote archive into the remote directory
+func extractCommand(remotePath string, remoteDir string) []string {
+	flags := "xf"
+	if strings.HasSuffix(remotePath, ".gz") {
+		flags = "xzf"
+	}
+	return []string{"sh", "-c", fmt.Sprintf("mkdir -p %s && tar %s %s -C %s", shellQuote(remoteDir), flags, shellQuote(remotePath), shellQuote(remoteDir))}
+}
+
+// archiveCommand archives the names of the remote directory into the remote path and prints its checksum
+func archiveCommand(remotePath string, remoteDir string, names []string) []string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, shellQuote(name))
+	}
+	return []string{"sh", "-c", fmt.Sprintf("tar cf %s -C %s -- %s && %s",
+		shellQuote(remotePath), shellQuote(remoteDir), strings.Join(quoted, " "), checksumCommand(remotePath))}
+}
+
+// parseChecksum parses the output of `sha256sum < file && wc -c < file`
+func parseChecksum(output string) (string, int64, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 3 {
+		return "", 0, fmt.Errorf("unexpected checksum output %q", output)
+	}
+
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("unexpected checksum output %q", output)
+	}
+
+	return fields[0], size, nil
+}
+
+// remoteExecutor executes commands over the exec API of the pods. Copies are verified with sha256 checksums.
+type remoteExecutor struct {
+	client kubernetes.Interface
+	config *rest.Config
+}
+
+func (it *remoteExecutor) Exec(ctx context.Context, pod *corev1.Pod, container string, cmd []string, streams Streams) error {
+	stderr := streams.Stderr
+	if streams.TTY {
+		stderr = nil
+	}
+
+	request := it.client.CoreV1().RESTClient().
+		Post().
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   cmd,
+			Stdin:     streams.Stdin != nil,
+			Stdout:    streams.Stdout != nil,
+			Stderr:    stderr != nil,
+			TTY:       streams.TTY,
+		}, scheme.ParameterCodec)
+
+	spdyExec, err := remotecommand.NewSPDYExecutor(it.config, "POST", request.URL())
+	if err != nil {
+		return err
+	}
+
+	return spdyExec.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  streams.Stdin,
+		Stdout: streams.Stdout,
+		Stderr: stderr,
+		Tty:    streams.TTY,
+	})
+}
+
+// capture executes the command in a container of the pod and returns its output
+func (it *remoteExecutor) capture(ctx context.Context, pod *corev1.Pod, container string, cmd []string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err := it.Exec(ctx, pod, container, cmd, Streams{Stdout: stdout, Stderr: stderr})
+	if err != nil {
+		return "", fmt.Errorf("%w - %s on %s/%s: %s", err, cmd, pod.Namespace, pod.Name, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+func (it *remoteExecutor) remove(ctx context.Context, pod *corev1.Pod, container string, remotePath string) {
+	_ = it.Exec(ctx, pod, container, []string{"rm", "-f", remotePath}, Streams{})
+}
+
+func (it *remoteExecutor) Upload(ctx context.Context, pod *corev1.Pod, container string, archivePath string, remoteDir string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	remotePath := remoteTempPath(path.Ext(archivePath))
+	defer it.remove(ctx, pod, container, remotePath)
+
+	reader := io.TeeReader(io.TeeReader(file, hash), newProgress("upload "+pod.Name, info.Size()))
+	stderr := &bytes.Buffer{}
+	err = it.Exec(ctx, pod, container, []string{"sh", "-c", "cat > " + shellQuote(remotePath)}, Streams{Stdin: reader, Stderr: stderr})
+	if err != nil {
+		return fmt.Errorf("upload into %s/%s: %v %s", pod.Namespace, pod.Name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	output, err := it.capture(ctx, pod, container, []string{"sh", "-c", checksumCommand(remotePath)})
+	if err != nil {
+		return err
+	}
+
+	checksum, size, err := parseChecksum(output)
+	if err != nil {
+		return err
+	}
+	if want := hex.EncodeToString(hash.Sum(nil)); checksum != want || size != info.Size() {
+		return fmt.Errorf("upload into %s/%s: checksum mismatch, got %s (%d bytes), want %s (%d bytes)", pod.Namespace, pod.Name, checksum, size, want, info.Size())
+	}
+
+	_, err = it.capture(ctx, pod, container, extractCommand(remotePath, remoteDir))
+
+	return err
+}
+
+func (it *remoteExecutor) Download(ctx context.Context, pod *corev1.Pod, container string, remoteDir string, names []string, localPath string) error {
+	remotePath := remoteTempPath(".tar")
+	defer it.remove(ctx, pod, container, remotePath)
+
+	output, err := it.capture(ctx, pod, container, archiveCommand(remotePath, remoteDir, names))
+	if err != nil {
+		return err
+	}
+
+	checksum, size, err := parseChecksum(output)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	stderr := &bytes.Buffer{}
+	writer := io.MultiWriter(file, hash, newProgress("download "+pod.Name, size))
+	err = it.Exec(ctx, pod, container, []string{"cat", remotePath}, Streams{Stdout: writer, Stderr: stderr})
+	if err != nil {
+		return fmt.Errorf("download from %s/%s: %v %s", pod.Namespace, pod.Name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if got := hex.EncodeToString(hash.Sum(nil)); got != checksum {
+		return fmt.Errorf("download from %s/%s: checksum mismatch, got %s, want %s", pod.Namespace, pod.Name, got, checksum)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package cluster
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{n: 512, want: "512 B"},
+		{n: 1536, want: "1.5 KiB"},
+		{n: 10 * 1024 * 1024, want: "10.0 MiB"},
+		{n: 3 * 1024 * 1024 * 1024, want: "3.0 GiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := formatBytes(tt.n); got != tt.want {
+				t.Errorf("formatBytes() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		checksum string
+		size     int64
+		wantErr  bool
+	}{
+		{
+			name:     "sha256sum and wc output",
+			output:   "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  /tmp/kubot-abc.tar\n4\n",
+			checksum: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			size:     4,
+		},
+		{name: "missing size", output: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  /tmp/kubot-abc.tar\n", wantErr: true},
+		{name: "invalid size", output: "9f86d0  /tmp/kubot-abc.tar\nfour\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checksum, size, err := parseChecksum(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseChecksum() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if checksum != tt.checksum || size != tt.size {
+				t.Errorf("parseChecksum() got = %v %v, want %v %v", checksum, size, tt.checksum, tt.size)
+			}
+		})
+	}
+}
+
+func TestArchiveCommand(t *testing.T) {
+	for _, tool := range []string{"sh", "tar", "sha256sum"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is not installed", tool)
+		}
+	}
+
+	root := t.TempDir()
+	remoteDir := filepath.Join(root, "output & results")
+	names := []string{"My Suite.robot", "Login & Logout.robot", "it's $(touch injected).robot"}
+	for _, name := range names {
+		if err := os.MkdirAll(filepath.Join(remoteDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(remoteDir, name, "output.xml"), []byte("<robot/>"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(cmd []string) string {
+		command := exec.Command(cmd[0], cmd[1:]...)
+		command.Dir = root
+		output, err := command.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %v %s", cmd, err, output)
+		}
+		return string(output)
+	}
+
+	remotePath := filepath.Join(root, "kubot archive.tar")
+	if _, _, err := parseChecksum(run(archiveCommand(remotePath, remoteDir, names))); err != nil {
+		t.Fatal(err)
+	}
+
+	extracted := filepath.Join(root, "extracted & copied")
+	run(extractCommand(remotePath, extracted))
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(extracted, name, "output.xml")); err != nil {
+			t.Errorf("extracted %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "injected")); err == nil {
+		t.Error("a file name has run a command in the shell")
+	}
+}
@@ -0,0 +1,219 @@
+// Package fake provides a cluster backed by a fake clientset and a recording executor for tests
+package fake
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"github.com/yusufcanb/kubot/pkg/cluster"
+	"io"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Command is a command executed in a container
+type Command struct {
+	Pod       string
+	Container string
+	Cmd       []string
+}
+
+// Upload is an archive uploaded into a container
+type Upload struct {
+	Pod       string
+	Container string
+	RemoteDir string
+	Files     []string // names of the archive entries
+}
+
+// Executor records the executed commands and uploads, downloads are served from Files
+type Executor struct {
+	mu sync.Mutex
+
+	Commands []Command
+	Uploads  []Upload
+
+	// Files are the contents of the files in the containers by their absolute paths
+	Files map[string]string
+	// ExecError returns the error of an executed command, commands succeed when nil
+	ExecError func(cmd []string) error
+}
+
+func (it *Executor) Exec(ctx context.Context, pod *corev1.Pod, container string, cmd []string, streams cluster.Streams) error {
+	it.mu.Lock()
+	it.Commands = append(it.Commands, Command{Pod: pod.Name, Container: container, Cmd: cmd})
+	it.mu.Unlock()
+
+	if it.ExecError != nil {
+		return it.ExecError(cmd)
+	}
+	return nil
+}
+
+// Executed returns the commands executed in the pods, joined by spaces
+func (it *Executor) Executed() []string {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	executed := make([]string, 0, len(it.Commands))
+	for _, command := range it.Commands {
+		executed = append(executed, strings.Join(command.Cmd, " "))
+	}
+	return executed
+}
+
+func (it *Executor) Upload(ctx context.Context, pod *corev1.Pod, container string, archivePath string, remoteDir string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(archivePath, ".gz") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		reader = gzipReader
+	}
+
+	files := make([]string, 0)
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		files = append(files, header.Name)
+	}
+
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	it.Uploads = append(it.Uploads, Upload{Pod: pod.Name, Container: container, RemoteDir: remoteDir, Files: files})
+
+	return nil
+}
+
+// Download writes the Files under remoteDir matching the names into a tar archive, "." matches every file
+func (it *Executor) Download(ctx context.Context, pod *corev1.Pod, container string, remoteDir string, names []string, localPath string) error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	filePaths := make([]string, 0, len(it.Files))
+	for filePath := range it.Files {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+
+	tarWriter := tar.NewWriter(file)
+	for _, filePath := range filePaths {
+		name := strings.TrimPrefix(filePath, strings.TrimSuffix(remoteDir, "/")+"/")
+		if name == filePath || !matches(name, names) {
+			continue
+		}
+
+		content := it.Files[filePath]
+		err = tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			return err
+		}
+		if _, err = tarWriter.Write([]byte(content)); err != nil {
+			return err
+		}
+	}
+
+	return tarWriter.Close()
+}
+
+func matches(name string, names []string) bool {
+	for _, n := range names {
+		if n == "." || n == name || strings.HasPrefix(name, path.Clean(n)+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NewCluster returns a cluster backed by a fake clientset. Like the API server and the controllers do, created
+// objects get a name for their generateName and a uid, claims are bound and pods are running right away.
+func NewCluster(namespace string, objects ...runtime.Object) (*cluster.Cluster, *kubefake.Clientset, *Executor) {
+	client := kubefake.NewSimpleClientset(objects...)
+
+	client.PrependReactor("create", "persistentvolumeclaims", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		claim := action.(k8stesting.CreateAction).GetObject().(*corev1.PersistentVolumeClaim)
+		claim.Status.Phase = corev1.ClaimBound
+		return false, nil, nil
+	})
+
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+		if pod.Status.Phase == "" {
+			pod.Status.Phase = corev1.PodRunning
+		}
+		return false, nil, nil
+	})
+
+	client.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		job := action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		if job.Spec.Selector == nil {
+			job.Spec.Selector = &metav1.LabelSelector{MatchLabels: job.Spec.Template.Labels}
+		}
+		return false, nil, nil
+	})
+
+	// prepended last, so it runs before the others
+	client.PrependReactor("create", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		object, err := meta.Accessor(action.(k8stesting.CreateAction).GetObject())
+		if err != nil {
+			return false, nil, nil
+		}
+		if object.GetName() == "" && object.GetGenerateName() != "" {
+			object.SetName(object.GetGenerateName() + rand.String(5))
+		}
+		if object.GetNamespace() == "" {
+			object.SetNamespace(action.GetNamespace())
+		}
+		object.SetUID(types.UID(rand.String(16)))
+		object.SetCreationTimestamp(metav1.Now())
+		return false, nil, nil
+	})
+
+	executor := &Executor{Files: make(map[string]string)}
+
+	return cluster.New(client, executor, namespace), client, executor
+}
+
+// FinishJobs makes the jobs created afterwards finish right away with the given condition
+func FinishJobs(client *kubefake.Clientset, conditionType batchv1.JobConditionType, reason string) {
+	client.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		job := action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+			Type:   conditionType,
+			Status: corev1.ConditionTrue,
+			Reason: reason,
+		})
+		return false, nil, nil
+	})
+}
@@ -0,0 +1,217 @@
+package gc
+
+import (
+	"context"
+	"fmt"
+	"github.com/yusufcanb/kubot/pkg/cluster"
+	"github.com/yusufcanb/kubot/pkg/suite"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+	"time"
+)
+
+const (
+	KindJob                   = "Job"
+	KindPod                   = "Pod"
+	KindPersistentVolumeClaim = "PersistentVolumeClaim"
+	KindConfigMap             = "ConfigMap"
+)
+
+// Options configures which runs are collected
+type Options struct {
+	AllNamespaces  bool
+	RunID          string        // only collect the given run
+	OlderThan      time.Duration // only collect runs without any resource created within the duration
+	IncludeRunning bool          // collect runs with running suites or merges as well
+}
+
+// Resource represents a resource created by kubot
+type Resource struct {
+	Kind      string
+	Namespace string
+	Name      string
+	CreatedAt time.Time
+	Running   bool
+}
+
+// Run groups the resources created by an execution
+type Run struct {
+	ID        string
+	Resources []Resource
+}
+
+// LastActivity returns the creation time of the newest resource of the run
+func (it *Run) LastActivity() time.Time {
+	var last time.Time
+	for _, r := range it.Resources {
+		if r.CreatedAt.After(last) {
+			last = r.CreatedAt
+		}
+	}
+	return last
+}
+
+// Running tells whether a suite or a merge of the run is still in progress
+func (it *Run) Running() bool {
+	for _, r := range it.Resources {
+		if r.Running {
+			return true
+		}
+	}
+	return false
+}
+
+type Collector struct {
+	cluster *cluster.Cluster
+}
+
+func (it *Collector) namespace(options Options) string {
+	if options.AllNamespaces {
+		return metav1.NamespaceAll
+	}
+	return it.cluster.DefaultNamespace()
+}
+
+func (it *Collector) listOptions(options Options) metav1.ListOptions {
+	selector := suite.ManagedBySelector()
+	if options.RunID != "" {
+		selector = fmt.Sprintf("%s,%s=%s", selector, suite.LabelRunID, options.RunID)
+	}
+	return metav1.ListOptions{LabelSelector: selector}
+}
+
+func jobRunning(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return false
+		}
+	}
+	return true
+}
+
+func podRunning(pod *corev1.Pod) bool {
+	// volume pods sleep until they are deleted, only merges and dependency installs count as running
+	component := pod.Labels[suite.LabelComponent]
+	if component != suite.ComponentMerger && component != suite.ComponentDependencies {
+		return false
+	}
+	return pod.Status.Phase == corev1.PodPending || pod.Status.Phase == corev1.PodRunning
+}
+
+// List returns the runs matching the options, oldest first
+func (it *Collector) List(ctx context.Context, options Options) ([]Run, error) {
+	namespace := it.namespace(options)
+	listOptions := it.listOptions(options)
+	client := it.cluster.Client()
+
+	runs := make(map[string]*Run)
+	add := func(meta metav1.ObjectMeta, kind string, running bool) {
+		id := meta.Labels[suite.LabelRunID]
+		if _, ok := runs[id]; !ok {
+			runs[id] = &Run{ID: id}
+		}
+		runs[id].Resources = append(runs[id].Resources, Resource{
+			Kind:      kind,
+			Namespace: meta.Namespace,
+			Name:      meta.Name,
+			CreatedAt: meta.CreationTimestamp.Time,
+			Running:   running,
+		})
+	}
+
+	jobs, err := client.BatchV1().Jobs(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error listing jobs: %v", err)
+	}
+	for i := range jobs.Items {
+		add(jobs.Items[i].ObjectMeta, KindJob, jobRunning(&jobs.Items[i]))
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %v", err)
+	}
+	for i := range pods.Items {
+		// pods of jobs are deleted along with their jobs
+		if pods.Items[i].Labels[suite.LabelComponent] == suite.ComponentSuite {
+			continue
+		}
+		add(pods.Items[i].ObjectMeta, KindPod, podRunning(&pods.Items[i]))
+	}
+
+	claims, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error listing persistent volume claims: %v", err)
+	}
+	for i := range claims.Items {
+		add(claims.Items[i].ObjectMeta, KindPersistentVolumeClaim, false)
+	}
+
+	configMaps, err := client.CoreV1().ConfigMaps(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error listing config maps: %v", err)
+	}
+	for i := range configMaps.Items {
+		add(configMaps.Items[i].ObjectMeta, KindConfigMap, false)
+	}
+
+	result := make([]Run, 0, len(runs))
+	for _, run := range runs {
+		if !options.IncludeRunning && run.Running() {
+			continue
+		}
+		if time.Since(run.LastActivity()) < options.OlderThan {
+			continue
+		}
+		result = append(result, *run)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastActivity().Before(result[j].LastActivity())
+	})
+
+	return result, nil
+}
+
+// Delete deletes every resource of the given runs, jobs first so the claims and config maps are no longer in use
+func (it *Collector) Delete(ctx context.Context, runs []Run) error {
+	client := it.cluster.Client()
+	propagation := metav1.DeletePropagationBackground
+	deleteOptions := metav1.DeleteOptions{PropagationPolicy: &propagation}
+
+	for _, kind := range []string{KindJob, KindPod, KindPersistentVolumeClaim, KindConfigMap} {
+		for _, run := range runs {
+			for _, r := range run.Resources {
+				if r.Kind != kind {
+					continue
+				}
+
+				var err error
+				switch kind {
+				case KindJob:
+					err = client.BatchV1().Jobs(r.Namespace).Delete(ctx, r.Name, deleteOptions)
+				case KindPod:
+					err = client.CoreV1().Pods(r.Namespace).Delete(ctx, r.Name, deleteOptions)
+				case KindPersistentVolumeClaim:
+					err = client.CoreV1().PersistentVolumeClaims(r.Namespace).Delete(ctx, r.Name, deleteOptions)
+				case KindConfigMap:
+					err = client.CoreV1().ConfigMaps(r.Namespace).Delete(ctx, r.Name, deleteOptions)
+				}
+				if err != nil && !apierrors.IsNotFound(err) {
+					return fmt.Errorf("error deleting %s %s/%s: %v", kind, r.Namespace, r.Name, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func NewCollector(c *cluster.Cluster) *Collector {
+	return &Collector{
+		cluster: c,
+	}
+}
@@ -0,0 +1,150 @@
+package gc
+
+import (
+	"context"
+	"github.com/yusufcanb/kubot/pkg/cluster/fake"
+	"github.com/yusufcanb/kubot/pkg/suite"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func objectMeta(name string, namespace string, runID string, component string, age time.Duration) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:              name,
+		Namespace:         namespace,
+		CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		Labels: map[string]string{
+			suite.LabelManagedBy: suite.ManagedByKubot,
+			suite.LabelRunID:     runID,
+			suite.LabelComponent: component,
+		},
+	}
+}
+
+func finishedJob(name string, runID string, age time.Duration) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: objectMeta(name, "kubot", runID, suite.ComponentSuite, age),
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+// testObjects are the resources of an old finished run, an old run still merging, a recent run and a run of
+// another namespace
+func testObjects() []runtime.Object {
+	return []runtime.Object{
+		finishedJob("job-old", "old", 3*time.Hour),
+		&corev1.PersistentVolumeClaim{ObjectMeta: objectMeta("pvc-old", "kubot", "old", suite.ComponentVolume, 3*time.Hour)},
+		&corev1.Pod{
+			ObjectMeta: objectMeta("pod-old", "kubot", "old", suite.ComponentVolume, 3*time.Hour),
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: objectMeta("pod-old-suite", "kubot", "old", suite.ComponentSuite, 3*time.Hour),
+			Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+		},
+
+		&corev1.ConfigMap{ObjectMeta: objectMeta("cm-merging", "kubot", "merging", suite.ComponentWorkspace, 2*time.Hour)},
+		&corev1.Pod{
+			ObjectMeta: objectMeta("pod-merging", "kubot", "merging", suite.ComponentMerger, 2*time.Hour),
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+
+		finishedJob("job-recent", "recent", 3*time.Hour),
+		&corev1.ConfigMap{ObjectMeta: objectMeta("cm-recent", "kubot", "recent", suite.ComponentWorkspace, 10*time.Minute)},
+
+		&corev1.PersistentVolumeClaim{ObjectMeta: objectMeta("pvc-other", "other", "other", suite.ComponentVolume, 5*time.Hour)},
+	}
+}
+
+func TestCollector_List(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		want    []string // run ids, oldest first
+	}{
+		{name: "finished runs", options: Options{}, want: []string{"old", "recent"}},
+		{name: "older than", options: Options{OlderThan: time.Hour}, want: []string{"old"}},
+		{name: "including running", options: Options{OlderThan: time.Hour, IncludeRunning: true}, want: []string{"old", "merging"}},
+		{name: "run id", options: Options{RunID: "recent"}, want: []string{"recent"}},
+		{name: "running run id", options: Options{RunID: "merging"}, want: []string{}},
+		{name: "all namespaces", options: Options{AllNamespaces: true, OlderThan: time.Hour}, want: []string{"other", "old"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _ := fake.NewCluster("kubot", testObjects()...)
+
+			runs, err := NewCollector(c).List(context.Background(), tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0, len(runs))
+			for _, run := range runs {
+				got = append(got, run.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollector_ListSkipsSuitePods(t *testing.T) {
+	c, _, _ := fake.NewCluster("kubot", testObjects()...)
+
+	runs, err := NewCollector(c).List(context.Background(), Options{RunID: "old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("List() got = %v, want the old run", runs)
+	}
+	for _, r := range runs[0].Resources {
+		if r.Name == "pod-old-suite" {
+			t.Errorf("List() got the suite pod %s, want it deleted along with its job", r.Name)
+		}
+	}
+}
+
+func TestCollector_Delete(t *testing.T) {
+	c, client, _ := fake.NewCluster("kubot", testObjects()...)
+	ctx := context.Background()
+	collector := NewCollector(c)
+
+	runs, err := collector.List(ctx, Options{OlderThan: time.Hour, IncludeRunning: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.ClearActions()
+	if err := collector.Delete(ctx, runs); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted := make([]string, 0)
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "delete" {
+			deleted = append(deleted, action.GetResource().Resource)
+		}
+	}
+	// jobs and pods are deleted before the claims and config maps they use
+	want := []string{"jobs", "pods", "pods", "persistentvolumeclaims", "configmaps"}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Errorf("Delete() deleted = %v, want %v", deleted, want)
+	}
+
+	runs, err = collector.List(ctx, Options{IncludeRunning: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].ID != "recent" {
+		t.Errorf("List() after Delete() got = %v, want only the recent run", runs)
+	}
+}
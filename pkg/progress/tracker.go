@@ -0,0 +1,204 @@
+// Package progress tracks the suites of a running execution and renders their progress
+package progress
+
+import (
+	"github.com/yusufcanb/kubot/pkg/batch"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State is the state of a suite in the run
+type State int
+
+const (
+	Queued  State = iota // waiting for a free slot
+	Pending              // started, its pod is not running yet
+	Running
+	Passed
+	Failed // failed tests or could not be executed
+)
+
+// phaseRunning is the phase of a running pod
+const phaseRunning = "Running"
+
+// SuiteStatus is the status of a started suite
+type SuiteStatus struct {
+	Name    string
+	Phase   string // phase of the suite pod, empty when there is no pod yet, Running for suites without pods
+	Elapsed time.Duration
+}
+
+// Snapshot is the progress of the run at a point in time
+type Snapshot struct {
+	Counts  map[State]int
+	Elapsed time.Duration
+	ETA     time.Duration // remaining time, negative when it cannot be estimated yet
+	Started []SuiteStatus // pending and running suites, the longest running first
+}
+
+type suiteState struct {
+	state     State
+	phase     string
+	startedAt time.Time
+}
+
+// Tracker keeps the states of the suites of a run
+type Tracker struct {
+	mu sync.Mutex
+
+	concurrency int
+	estimates   map[string]time.Duration // durations of the previous runs
+	startedAt   time.Time                // time the first suites are queued at
+
+	suites    map[string]*suiteState
+	phases    map[string]string // nil when the executor has no pods
+	durations []time.Duration   // durations of the suites finished in this run
+}
+
+// Queue marks the given suites as waiting to be started, e.g. before they are rerun
+func (it *Tracker) Queue(suites []string) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	if it.startedAt.IsZero() {
+		it.startedAt = time.Now()
+	}
+	for _, name := range suites {
+		it.suites[name] = &suiteState{state: Queued}
+	}
+}
+
+// OnEvent updates the state of a suite on the scheduler events
+func (it *Tracker) OnEvent(e batch.Event) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	suite, ok := it.suites[e.Suite]
+	if !ok {
+		suite = &suiteState{}
+		it.suites[e.Suite] = suite
+	}
+
+	switch {
+	case e.Type == batch.SuiteStarted:
+		suite.state = Pending
+		suite.startedAt = e.Time
+	case e.Err != nil:
+		suite.state = Failed
+		it.durations = append(it.durations, e.Time.Sub(suite.startedAt))
+	default:
+		suite.state = Passed
+		it.durations = append(it.durations, e.Time.Sub(suite.startedAt))
+	}
+}
+
+// SetPhases updates the phases of the suite pods by suite name, nil means the suites run without pods
+func (it *Tracker) SetPhases(phases map[string]string) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	it.phases = phases
+}
+
+// state returns the state of a suite, started suites are running once their pod is
+func (it *Tracker) state(name string, suite *suiteState) State {
+	if suite.state != Pending {
+		return suite.state
+	}
+	if it.phases == nil || it.phases[name] == phaseRunning {
+		return Running
+	}
+	return Pending
+}
+
+// estimate returns the expected duration of a suite, the average duration of this run for unknown suites
+func (it *Tracker) estimate(name string) (time.Duration, bool) {
+	if duration, ok := it.estimates[name]; ok {
+		return duration, true
+	}
+	if len(it.durations) == 0 {
+		return 0, false
+	}
+
+	var total time.Duration
+	for _, duration := range it.durations {
+		total += duration
+	}
+	return total / time.Duration(len(it.durations)), true
+}
+
+// Snapshot returns the progress of the run at the given time
+func (it *Tracker) Snapshot(now time.Time) Snapshot {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	snapshot := Snapshot{
+		Counts:  make(map[State]int),
+		Elapsed: now.Sub(it.startedAt),
+		Started: make([]SuiteStatus, 0),
+	}
+
+	// the remaining work is spread over the slots, but never finishes before the longest running suite
+	var remaining, longest time.Duration
+	estimated := true
+	for name, suite := range it.suites {
+		state := it.state(name, suite)
+		snapshot.Counts[state]++
+
+		if state != Queued && state != Pending && state != Running {
+			continue
+		}
+
+		estimate, ok := it.estimate(name)
+		estimated = estimated && ok
+		if state == Queued {
+			remaining += estimate
+			continue
+		}
+
+		phase := it.phases[name]
+		if it.phases == nil {
+			phase = phaseRunning
+		}
+
+		elapsed := now.Sub(suite.startedAt)
+		snapshot.Started = append(snapshot.Started, SuiteStatus{Name: name, Phase: phase, Elapsed: elapsed})
+		if left := estimate - elapsed; left > 0 {
+			remaining += left
+			if left > longest {
+				longest = left
+			}
+		}
+	}
+
+	snapshot.ETA = -1
+	if estimated {
+		snapshot.ETA = remaining / time.Duration(it.concurrency)
+		if longest > snapshot.ETA {
+			snapshot.ETA = longest
+		}
+	}
+
+	sort.Slice(snapshot.Started, func(i, j int) bool {
+		if snapshot.Started[i].Elapsed != snapshot.Started[j].Elapsed {
+			return snapshot.Started[i].Elapsed > snapshot.Started[j].Elapsed
+		}
+		return snapshot.Started[i].Name < snapshot.Started[j].Name
+	})
+
+	return snapshot
+}
+
+// NewTracker creates a tracker of a run with the given concurrency, estimates are the suite durations of the previous runs
+func NewTracker(concurrency int, estimates map[string]time.Duration) *Tracker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Tracker{
+		concurrency: concurrency,
+		estimates:   estimates,
+		suites:      make(map[string]*suiteState),
+	}
+}
@@ -0,0 +1,58 @@
+package progress
+
+import (
+	"errors"
+	"github.com/yusufcanb/kubot/pkg/batch"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTracker_Snapshot(t *testing.T) {
+	start := time.Now()
+	tracker := NewTracker(2, map[string]time.Duration{"long.robot": 10 * time.Minute})
+	tracker.Queue([]string{"long.robot", "a.robot", "b.robot", "c.robot", "d.robot"})
+
+	tracker.OnEvent(batch.Event{Type: batch.SuiteStarted, Suite: "long.robot", Time: start})
+	tracker.OnEvent(batch.Event{Type: batch.SuiteStarted, Suite: "a.robot", Time: start})
+	tracker.OnEvent(batch.Event{Type: batch.SuiteFinished, Suite: "a.robot", Time: start.Add(time.Minute), Err: errors.New("suite has failed tests")})
+	tracker.OnEvent(batch.Event{Type: batch.SuiteStarted, Suite: "b.robot", Time: start.Add(time.Minute)})
+	tracker.OnEvent(batch.Event{Type: batch.SuiteFinished, Suite: "b.robot", Time: start.Add(4 * time.Minute)})
+	tracker.OnEvent(batch.Event{Type: batch.SuiteStarted, Suite: "c.robot", Time: start.Add(4 * time.Minute)})
+	tracker.SetPhases(map[string]string{"long.robot": "Running", "c.robot": "Pending"})
+
+	snapshot := tracker.Snapshot(start.Add(5 * time.Minute))
+
+	wantCounts := map[State]int{Queued: 1, Pending: 1, Running: 1, Passed: 1, Failed: 1}
+	if !reflect.DeepEqual(snapshot.Counts, wantCounts) {
+		t.Errorf("Snapshot() counts got = %v, want %v", snapshot.Counts, wantCounts)
+	}
+
+	wantStarted := []SuiteStatus{
+		{Name: "long.robot", Phase: "Running", Elapsed: 5 * time.Minute},
+		{Name: "c.robot", Phase: "Pending", Elapsed: time.Minute},
+	}
+	if !reflect.DeepEqual(snapshot.Started, wantStarted) {
+		t.Errorf("Snapshot() started got = %v, want %v", snapshot.Started, wantStarted)
+	}
+
+	// long.robot has 5m left, c.robot 1m and d.robot 2m by the average of this run, spread over 2 slots
+	if snapshot.ETA != 5*time.Minute {
+		t.Errorf("Snapshot() eta got = %v, want the remaining time of the longest suite", snapshot.ETA)
+	}
+}
+
+func TestTracker_SnapshotUnknownETA(t *testing.T) {
+	start := time.Now()
+	tracker := NewTracker(2, nil)
+	tracker.Queue([]string{"a.robot", "b.robot"})
+	tracker.OnEvent(batch.Event{Type: batch.SuiteStarted, Suite: "a.robot", Time: start})
+
+	snapshot := tracker.Snapshot(start.Add(time.Minute))
+	if snapshot.ETA >= 0 {
+		t.Errorf("Snapshot() eta got = %v, want unknown before any suite has finished", snapshot.ETA)
+	}
+	if snapshot.Counts[Running] != 1 || snapshot.Started[0].Phase != "Running" {
+		t.Errorf("Snapshot() got = %+v, want suites without pods running once started", snapshot)
+	}
+}
@@ -0,0 +1,200 @@
+package progress
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxListed is the maximum number of started suites listed by the interactive view
+const maxListed = 10
+
+// refreshInterval, statusInterval and phaseInterval configure how often the interactive view is redrawn,
+// the plain status lines are logged and the pod phases are refreshed
+var (
+	refreshInterval = time.Second
+	statusInterval  = 30 * time.Second
+	phaseInterval   = 5 * time.Second
+)
+
+// PhasesFunc returns the phases of the suite pods by suite name, nil when the suites run without pods
+type PhasesFunc func(ctx context.Context) (map[string]string, error)
+
+// View renders the progress of a run. On a terminal the view is redrawn in place below the other output,
+// which has to be written through the view. Otherwise, status lines are logged periodically.
+type View struct {
+	*Tracker
+
+	out         *os.File
+	interactive bool
+
+	mu    sync.Mutex
+	block string // the view drawn at the bottom of the terminal
+}
+
+// Write prints p above the view, p must consist of complete lines
+func (it *View) Write(p []byte) (int, error) {
+	if !it.interactive {
+		return it.out.Write(p)
+	}
+
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	it.clear()
+	n, err := it.out.Write(p)
+	_, _ = io.WriteString(it.out, it.block)
+
+	return n, err
+}
+
+// clear erases the view, the cursor is left where the view has started
+func (it *View) clear() {
+	if lines := strings.Count(it.block, "\n"); lines > 0 {
+		_, _ = fmt.Fprintf(it.out, "\x1b[%dA\r\x1b[J", lines)
+	}
+}
+
+func (it *View) redraw(block string) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	it.clear()
+	it.block = block
+	_, _ = io.WriteString(it.out, it.block)
+}
+
+// finish draws the last state of the view, it stays on the terminal above the following output
+func (it *View) finish() {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	it.clear()
+	_, _ = io.WriteString(it.out, renderBlock(it.Snapshot(time.Now()), it.width()))
+	it.block = ""
+}
+
+func (it *View) width() int {
+	width, _, err := term.GetSize(int(it.out.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// Run renders the view until the context is cancelled, logs are printed above the interactive view meanwhile
+func (it *View) Run(ctx context.Context, phases PhasesFunc) {
+	interval := statusInterval
+	if it.interactive {
+		interval = refreshInterval
+
+		log.SetOutput(it)
+		defer log.SetOutput(os.Stderr)
+	}
+
+	refreshPhases := func() {
+		current, err := phases(ctx)
+		if err != nil {
+			log.Debugf("refreshing pod phases: %s", err)
+			return
+		}
+		it.SetPhases(current)
+	}
+	refreshPhases()
+
+	render := time.NewTicker(interval)
+	defer render.Stop()
+	refresh := time.NewTicker(phaseInterval)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if it.interactive {
+				it.finish()
+			}
+			return
+		case <-refresh.C:
+			refreshPhases()
+		case <-render.C:
+			if it.interactive {
+				it.redraw(renderBlock(it.Snapshot(time.Now()), it.width()))
+			} else {
+				log.Info(renderLine(it.Snapshot(time.Now())))
+			}
+		}
+	}
+}
+
+// IsTerminal reports whether f is a terminal, so the view can be drawn interactively into it
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// NewView creates a view of the given tracker rendered into out, status lines are logged when it is not interactive
+func NewView(tracker *Tracker, out *os.File, interactive bool) *View {
+	return &View{
+		Tracker:     tracker,
+		out:         out,
+		interactive: interactive,
+	}
+}
+
+// formatDuration formats a duration rounded to seconds, e.g. 1h2m3s
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+func formatETA(eta time.Duration) string {
+	if eta < 0 {
+		return "unknown"
+	}
+	return formatDuration(eta)
+}
+
+// renderLine renders the counts of a snapshot into a single status line
+func renderLine(s Snapshot) string {
+	return fmt.Sprintf("progress: %d queued, %d pending, %d running, %d passed, %d failed, %s elapsed, eta %s",
+		s.Counts[Queued], s.Counts[Pending], s.Counts[Running], s.Counts[Passed], s.Counts[Failed],
+		formatDuration(s.Elapsed), formatETA(s.ETA))
+}
+
+// renderBlock renders a snapshot into the lines of the interactive view, lines are cut at the given width
+func renderBlock(s Snapshot, width int) string {
+	lines := []string{
+		fmt.Sprintf("%d queued  %d pending  %d running  %d passed  %d failed",
+			s.Counts[Queued], s.Counts[Pending], s.Counts[Running], s.Counts[Passed], s.Counts[Failed]),
+		fmt.Sprintf("%s elapsed, eta %s", formatDuration(s.Elapsed), formatETA(s.ETA)),
+	}
+
+	for i, suite := range s.Started {
+		if i == maxListed {
+			lines = append(lines, fmt.Sprintf("  ... %d more", len(s.Started)-maxListed))
+			break
+		}
+
+		phase := suite.Phase
+		if phase == "" {
+			phase = "-"
+		}
+		lines = append(lines, fmt.Sprintf("  %-9s %8s  %s", phase, formatDuration(suite.Elapsed), suite.Name))
+	}
+
+	var sb strings.Builder
+	for _, line := range lines {
+		// wrapped lines would break erasing the view
+		if runes := []rune(line); width > 0 && len(runes) >= width {
+			line = string(runes[:width-1])
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
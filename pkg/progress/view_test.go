@@ -0,0 +1,27 @@
+package progress
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderBlock(t *testing.T) {
+	snapshot := Snapshot{
+		Counts:  map[State]int{Queued: 3, Running: 1, Passed: 2},
+		Elapsed: 90 * time.Second,
+		ETA:     -1,
+		Started: []SuiteStatus{{Name: "admin/users/create_a_user_with_a_very_long_name.robot", Phase: "Running", Elapsed: 61 * time.Second}},
+	}
+
+	want := "3 queued  0 pending  1 running  2 passed  0 failed\n" +
+		"1m30s elapsed, eta unknown\n" +
+		"  Running       1m1s  admin/users/create_a_user_with_a_very\n"
+	if got := renderBlock(snapshot, 60); got != want {
+		t.Errorf("renderBlock() got = %q, want %q", got, want)
+	}
+
+	if got := renderLine(snapshot); !strings.HasPrefix(got, "progress: 3 queued, 0 pending, 1 running, 2 passed, 0 failed") {
+		t.Errorf("renderLine() got = %q", got)
+	}
+}
@@ -0,0 +1,218 @@
+package result
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	StatusPass   = "PASS"
+	StatusFail   = "FAIL"
+	StatusSkip   = "SKIP"
+	StatusNotRun = "NOT RUN"
+)
+
+// legacyTimeFormat is the timestamp format of Robot Framework 6 and older
+const legacyTimeFormat = "20060102 15:04:05.000"
+
+// isoTimeFormat is the timestamp format of Robot Framework 7 and newer
+const isoTimeFormat = "2006-01-02T15:04:05.999999"
+
+// Status represents the status element of suites, tests and keywords.
+// Robot Framework 7 replaced the starttime and endtime attributes with start and elapsed.
+type Status struct {
+	Status    string `xml:"status,attr"`
+	StartTime string `xml:"starttime,attr"`
+	EndTime   string `xml:"endtime,attr"`
+	Start     string `xml:"start,attr"`
+	ElapsedS  string `xml:"elapsed,attr"`
+	Message   string `xml:",chardata"`
+}
+
+// StartedAt returns the start time of the item
+func (it *Status) StartedAt() (time.Time, error) {
+	if it.Start != "" {
+		return time.ParseInLocation(isoTimeFormat, it.Start, time.Local)
+	}
+	return parseLegacyTime(it.StartTime)
+}
+
+// Elapsed returns the execution time of the item
+func (it *Status) Elapsed() time.Duration {
+	if it.ElapsedS != "" {
+		seconds, err := strconv.ParseFloat(it.ElapsedS, 64)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+
+	start, err := parseLegacyTime(it.StartTime)
+	if err != nil {
+		return 0
+	}
+	end, err := parseLegacyTime(it.EndTime)
+	if err != nil {
+		return 0
+	}
+
+	return end.Sub(start)
+}
+
+func parseLegacyTime(value string) (time.Time, error) {
+	if value == "" || value == "N/A" {
+		return time.Time{}, fmt.Errorf("missing timestamp")
+	}
+	return time.ParseInLocation(legacyTimeFormat, value, time.Local)
+}
+
+// Message represents a log message of a keyword or an execution error
+type Message struct {
+	Timestamp string `xml:"timestamp,attr"`
+	Time      string `xml:"time,attr"`
+	Level     string `xml:"level,attr"`
+	HTML      bool   `xml:"html,attr"`
+	Text      string `xml:",chardata"`
+}
+
+// Keyword represents a keyword call including setups and teardowns
+type Keyword struct {
+	Name     string    `xml:"name,attr"`
+	Library  string    `xml:"library,attr"`
+	Owner    string    `xml:"owner,attr"`
+	Type     string    `xml:"type,attr"`
+	Doc      string    `xml:"doc"`
+	Args     []string  `xml:"arg"`
+	Tags     []string  `xml:"tag"`
+	Keywords []Keyword `xml:"kw"`
+	Messages []Message `xml:"msg"`
+	Status   Status    `xml:"status"`
+}
+
+// Test represents a test case or task
+type Test struct {
+	ID       string    `xml:"id,attr"`
+	Name     string    `xml:"name,attr"`
+	Doc      string    `xml:"doc"`
+	Tags     []string  `xml:"tag"`
+	Keywords []Keyword `xml:"kw"`
+	Status   Status    `xml:"status"`
+}
+
+// Suite represents a suite directory or file
+type Suite struct {
+	ID       string    `xml:"id,attr"`
+	Name     string    `xml:"name,attr"`
+	Source   string    `xml:"source,attr"`
+	Doc      string    `xml:"doc"`
+	Keywords []Keyword `xml:"kw"`
+	Suites   []Suite   `xml:"suite"`
+	Tests    []Test    `xml:"test"`
+	Status   Status    `xml:"status"`
+}
+
+// Statistics holds the test counts of a suite
+type Statistics struct {
+	Total   int
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+// Statistics counts the tests of the suite and its child suites
+func (it *Suite) Statistics() Statistics {
+	stats := Statistics{}
+	for _, test := range it.Tests {
+		stats.Total++
+		switch test.Status.Status {
+		case StatusPass:
+			stats.Passed++
+		case StatusFail:
+			stats.Failed++
+		default:
+			stats.Skipped++
+		}
+	}
+
+	for i := range it.Suites {
+		child := it.Suites[i].Statistics()
+		stats.Total += child.Total
+		stats.Passed += child.Passed
+		stats.Failed += child.Failed
+		stats.Skipped += child.Skipped
+	}
+
+	return stats
+}
+
+// Walk calls fn for the suite and every child suite along with their long names
+func (it *Suite) Walk(fn func(longName string, suite *Suite)) {
+	it.walk("", fn)
+}
+
+func (it *Suite) walk(parent string, fn func(longName string, suite *Suite)) {
+	longName := it.Name
+	if parent != "" {
+		longName = parent + "." + it.Name
+	}
+
+	fn(longName, it)
+	for i := range it.Suites {
+		it.Suites[i].walk(longName, fn)
+	}
+}
+
+// Output represents a Robot Framework output.xml
+type Output struct {
+	XMLName       xml.Name  `xml:"robot"`
+	Generator     string    `xml:"generator,attr"`
+	Generated     string    `xml:"generated,attr"`
+	RPA           bool      `xml:"rpa,attr"`
+	SchemaVersion int       `xml:"schemaversion,attr"`
+	Suite         Suite     `xml:"suite"`
+	Errors        []Message `xml:"errors>msg"`
+}
+
+// Parse reads an output.xml from the given reader
+func Parse(r io.Reader) (*Output, error) {
+	output := Output{}
+	if err := xml.NewDecoder(r).Decode(&output); err != nil {
+		return nil, fmt.Errorf("failed to parse output: %v", err)
+	}
+
+	return &output, nil
+}
+
+// ParseFile reads the output.xml at the given path
+func ParseFile(path string) (*Output, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	output, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return output, nil
+}
+
+// FailedTests returns the long names of the failed tests
+func (it *Output) FailedTests() []string {
+	failed := make([]string, 0)
+	it.Suite.Walk(func(longName string, suite *Suite) {
+		for _, test := range suite.Tests {
+			if test.Status.Status == StatusFail {
+				failed = append(failed, longName+"."+test.Name)
+			}
+		}
+	})
+
+	return failed
+}
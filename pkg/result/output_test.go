@@ -0,0 +1,163 @@
+package result
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const legacyOutput = `<?xml version="1.0" encoding="UTF-8"?>
+<robot generator="Rebot 6.1.1 (Python 3.11.4 on linux)" generated="20230801 10:00:05.000" rpa="false" schemaversion="4">
+<suite id="s1" name="Kubot Results">
+<suite id="s1-s1" name="Scripts" source="/data/workspace/scripts">
+<suite id="s1-s1-s1" name="Google" source="/data/workspace/scripts/google.robot">
+<kw name="Task Setup" type="SETUP">
+<status status="PASS" starttime="20230801 10:00:00.000" endtime="20230801 10:00:01.000"/>
+</kw>
+<test id="s1-s1-s1-t1" name="Visit No Hits Page" line="4">
+<kw name="New Page" library="Browser">
+<arg>https://www.google.com</arg>
+<msg timestamp="20230801 10:00:01.500" level="INFO">Successfully initialized new page object</msg>
+<status status="PASS" starttime="20230801 10:00:01.000" endtime="20230801 10:00:02.000"/>
+</kw>
+<tag>smoke</tag>
+<status status="PASS" starttime="20230801 10:00:01.000" endtime="20230801 10:00:02.000"/>
+</test>
+<test id="s1-s1-s1-t2" name="Visit Direct Hit Page" line="12">
+<tag>smoke</tag>
+<tag>flaky</tag>
+<status status="FAIL" starttime="20230801 10:00:02.000" endtime="20230801 10:00:03.500">TimeoutError: page.goto: Timeout 10000ms exceeded.</status>
+</test>
+<test id="s1-s1-s1-t3" name="Visit Multiple Hits Page" line="20">
+<status status="SKIP" starttime="20230801 10:00:03.500" endtime="20230801 10:00:03.500">Skipped with --skip option.</status>
+</test>
+<status status="FAIL" starttime="20230801 10:00:00.000" endtime="20230801 10:00:04.000"/>
+</suite>
+<status status="FAIL" starttime="20230801 10:00:00.000" endtime="20230801 10:00:04.000"/>
+</suite>
+<status status="FAIL" starttime="N/A" endtime="N/A"/>
+</suite>
+<errors>
+<msg timestamp="20230801 10:00:00.100" level="WARN">Error in file 'google.robot': Non-existing setting 'Foo'.</msg>
+</errors>
+</robot>
+`
+
+const isoOutput = `<?xml version="1.0" encoding="UTF-8"?>
+<robot generator="Robot 7.0 (Python 3.11.4 on linux)" generated="2024-01-15T10:00:05.123456" rpa="false" schemaversion="5">
+<suite id="s1" name="Bing" source="/data/workspace/scripts/bing.robot">
+<test id="s1-t1" name="Search" line="4">
+<kw name="Log" owner="BuiltIn">
+<msg time="2024-01-15T10:00:01.000000" level="INFO">ok</msg>
+<arg>ok</arg>
+<status status="PASS" start="2024-01-15T10:00:01.000000" elapsed="0.001"/>
+</kw>
+<status status="PASS" start="2024-01-15T10:00:01.000000" elapsed="2.500"/>
+</test>
+<status status="PASS" start="2024-01-15T10:00:00.500000" elapsed="3.25"/>
+</suite>
+<errors>
+</errors>
+</robot>
+`
+
+func TestParse_Legacy(t *testing.T) {
+	output, err := Parse(strings.NewReader(legacyOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output.SchemaVersion != 4 {
+		t.Errorf("SchemaVersion got = %v, want %v", output.SchemaVersion, 4)
+	}
+
+	google := output.Suite.Suites[0].Suites[0]
+	if google.Source != "/data/workspace/scripts/google.robot" {
+		t.Errorf("Source got = %v", google.Source)
+	}
+	if len(google.Keywords) != 1 || google.Keywords[0].Type != "SETUP" {
+		t.Errorf("Keywords got = %v, want the suite setup", google.Keywords)
+	}
+
+	test := google.Tests[0]
+	if test.Keywords[0].Library != "Browser" || test.Keywords[0].Args[0] != "https://www.google.com" {
+		t.Errorf("Keyword got = %+v", test.Keywords[0])
+	}
+	if test.Keywords[0].Messages[0].Text != "Successfully initialized new page object" {
+		t.Errorf("Message got = %+v", test.Keywords[0].Messages[0])
+	}
+
+	failed := google.Tests[1]
+	if len(failed.Tags) != 2 || failed.Status.Message != "TimeoutError: page.goto: Timeout 10000ms exceeded." {
+		t.Errorf("Test got = %+v", failed)
+	}
+	if elapsed := failed.Status.Elapsed(); elapsed != 1500*time.Millisecond {
+		t.Errorf("Elapsed() got = %v, want %v", elapsed, 1500*time.Millisecond)
+	}
+
+	want := Statistics{Total: 3, Passed: 1, Failed: 1, Skipped: 1}
+	if stats := output.Suite.Statistics(); stats != want {
+		t.Errorf("Statistics() got = %+v, want %+v", stats, want)
+	}
+
+	wantFailed := []string{"Kubot Results.Scripts.Google.Visit Direct Hit Page"}
+	if failedTests := output.FailedTests(); !reflect.DeepEqual(failedTests, wantFailed) {
+		t.Errorf("FailedTests() got = %v, want %v", failedTests, wantFailed)
+	}
+
+	if output.Suite.Status.Elapsed() != 0 {
+		t.Errorf("Elapsed() got = %v for N/A timestamps, want 0", output.Suite.Status.Elapsed())
+	}
+
+	if len(output.Errors) != 1 || output.Errors[0].Level != "WARN" {
+		t.Errorf("Errors got = %+v", output.Errors)
+	}
+}
+
+func TestParse_ISO(t *testing.T) {
+	output, err := Parse(strings.NewReader(isoOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := output.Suite.Status.Elapsed(); elapsed != 3250*time.Millisecond {
+		t.Errorf("Elapsed() got = %v, want %v", elapsed, 3250*time.Millisecond)
+	}
+
+	startedAt, err := output.Suite.Status.StartedAt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 1, 15, 10, 0, 0, 500000000, time.Local); !startedAt.Equal(want) {
+		t.Errorf("StartedAt() got = %v, want %v", startedAt, want)
+	}
+
+	if kw := output.Suite.Tests[0].Keywords[0]; kw.Owner != "BuiltIn" || kw.Messages[0].Time == "" {
+		t.Errorf("Keyword got = %+v", kw)
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	output, err := Parse(strings.NewReader(legacyOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSummary(&buf, output); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("WriteSummary() got %d lines, want %d:\n%s", len(lines), 4, buf.String())
+	}
+	if !strings.HasPrefix(lines[1], "Kubot Results.Scripts.Google ") {
+		t.Errorf("WriteSummary() suite row got = %v", lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "Kubot Results") || !strings.Contains(lines[3], "FAIL") {
+		t.Errorf("WriteSummary() total row got = %v", lines[3])
+	}
+}
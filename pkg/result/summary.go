@@ -0,0 +1,31 @@
+package result
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteSummary writes a table of the pass, fail and skip counts of every suite having tests
+func WriteSummary(w io.Writer, output *Output) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "SUITE\tPASS\tFAIL\tSKIP\tTOTAL\tELAPSED\tSTATUS")
+	output.Suite.Walk(func(longName string, suite *Suite) {
+		if len(suite.Tests) == 0 {
+			return
+		}
+		writeRow(tw, longName, suite)
+	})
+	fmt.Fprintln(tw, "\t\t\t\t\t\t")
+	writeRow(tw, output.Suite.Name, &output.Suite)
+
+	return tw.Flush()
+}
+
+func writeRow(w io.Writer, name string, suite *Suite) {
+	stats := suite.Statistics()
+	fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\t%s\n", name, stats.Passed, stats.Failed, stats.Skipped, stats.Total,
+		suite.Status.Elapsed().Round(time.Second), suite.Status.Status)
+}
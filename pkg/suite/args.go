@@ -0,0 +1,165 @@
+package suite
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// robotOptions are the robot options kubot sets itself, by long name and short name
+var robotOptions = map[string]string{
+	"outputdir":         "d",
+	"output":            "o",
+	"log":               "l",
+	"report":            "r",
+	"suite":             "s",
+	"test":              "t",
+	"task":              "",
+	"name":              "N",
+	"rerunfailed":       "R",
+	"rerunfailedsuites": "S",
+	"timestampoutputs":  "T",
+	"nostatusrc":        "",
+	"argumentfile":      "A",
+}
+
+// rebotOptions are the rebot options kubot sets itself, by long name and short name
+var rebotOptions = map[string]string{
+	"merge":            "R",
+	"outputdir":        "d",
+	"output":           "o",
+	"log":              "l",
+	"report":           "r",
+	"name":             "N",
+	"starttime":        "",
+	"endtime":          "",
+	"nostatusrc":       "",
+	"timestampoutputs": "T",
+	"argumentfile":     "A",
+}
+
+// robotValueOptions are the robot options taking a value, by long name and short name
+var robotValueOptions = map[string]string{
+	"language": "", "extension": "F", "parser": "", "name": "N", "doc": "D", "metadata": "M", "settag": "G",
+	"test": "t", "task": "", "suite": "s", "include": "i", "exclude": "e", "rerunfailed": "R",
+	"rerunfailedsuites": "S", "skip": "", "skiponfailure": "", "variable": "v", "variablefile": "V",
+	"outputdir": "d", "output": "o", "log": "l", "report": "r", "xunit": "x", "debugfile": "b", "logtitle": "",
+	"reporttitle": "", "reportbackground": "", "maxerrorlines": "", "maxassignlength": "", "loglevel": "L",
+	"suitestatlevel": "", "tagstatinclude": "", "tagstatexclude": "", "tagstatcombine": "", "tagdoc": "",
+	"tagstatlink": "", "expandkeywords": "", "removekeywords": "", "flattenkeywords": "", "listener": "",
+	"console": "", "consolewidth": "W", "consolecolors": "C", "consolelinks": "", "consolemarkers": "K",
+	"pythonpath": "P", "argumentfile": "A", "randomize": "", "prerunmodifier": "", "prerebotmodifier": "",
+	"parseinclude": "",
+}
+
+// rebotValueOptions are the rebot options taking a value, by long name and short name
+var rebotValueOptions = map[string]string{
+	"name": "N", "doc": "D", "metadata": "M", "settag": "G", "test": "t", "task": "", "suite": "s",
+	"include": "i", "exclude": "e", "outputdir": "d", "output": "o", "log": "l", "report": "r", "xunit": "x",
+	"logtitle": "", "reporttitle": "", "reportbackground": "", "loglevel": "L", "suitestatlevel": "",
+	"tagstatinclude": "", "tagstatexclude": "", "tagstatcombine": "", "tagdoc": "", "tagstatlink": "",
+	"expandkeywords": "", "removekeywords": "", "flattenkeywords": "", "starttime": "", "endtime": "",
+	"console": "", "consolecolors": "C", "consolelinks": "", "pythonpath": "P", "argumentfile": "A",
+	"prerebotmodifier": "", "parseinclude": "",
+}
+
+// selectionOptions are the robot options selecting the tests, they may leave a suite without tests
+var selectionOptions = map[string]string{"include": "i", "exclude": "e", "test": "t", "task": ""}
+
+// ValidateRobotArgs rejects the robot arguments conflicting with the options kubot controls, e.g. the output paths
+func ValidateRobotArgs(args []string) error {
+	return validateArgs("robot", args, robotOptions, robotValueOptions)
+}
+
+// ValidateRebotArgs rejects the rebot arguments conflicting with the options kubot controls, e.g. the output paths
+func ValidateRebotArgs(args []string) error {
+	return validateArgs("rebot", args, rebotOptions, rebotValueOptions)
+}
+
+// selectsTests tells whether the robot arguments select tests, e.g. --include or --exclude
+func selectsTests(args []string) bool {
+	options, _ := parseOptions(args, robotValueOptions)
+	for _, option := range options {
+		if _, ok := matchOption(option, selectionOptions); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateArgs checks every option of the arguments against the controlled ones
+func validateArgs(command string, args []string, controlled map[string]string, values map[string]string) error {
+	options, err := parseOptions(args, values)
+	if err != nil {
+		return fmt.Errorf("%s %v", command, err)
+	}
+
+	for _, option := range options {
+		if name, ok := matchOption(option, controlled); ok {
+			return fmt.Errorf("%s argument %s conflicts with --%s set by kubot", command, option, name)
+		}
+	}
+
+	return nil
+}
+
+// parseOptions returns the options of the arguments, the values of the options taking one are skipped
+// even when they start with a hyphen, e.g. --variable -x. Kubot appends the data sources after the arguments,
+// so anything else than an option or its value is rejected, including --.
+func parseOptions(args []string, values map[string]string) ([]string, error) {
+	options := make([]string, 0)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || arg == "-" || !strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("argument %s is not an option, the data sources are set by kubot", arg)
+		}
+		options = append(options, arg)
+
+		// values given as --name=value or -Nvalue are part of the option
+		if (strings.HasPrefix(arg, "--") && strings.Contains(arg, "=")) || (!strings.HasPrefix(arg, "--") && len(arg) > 2) {
+			continue
+		}
+		if _, ok := matchOption(arg, values); ok {
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("argument %s is missing its value", arg)
+			}
+			i++
+		}
+	}
+
+	return options, nil
+}
+
+// matchOption returns the first of the options, in name order, the option given on the command line may be.
+// Robot accepts long options in any case, without hyphens and shortened to an unambiguous prefix, so an option
+// matches when its normalized name is a prefix of a known one, e.g. --OutputDir, --output-dir or --outputd for
+// --outputdir. Short options are case sensitive.
+func matchOption(option string, known map[string]string) (string, bool) {
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if strings.HasPrefix(option, "--") {
+		name, _, _ := strings.Cut(strings.TrimPrefix(option, "--"), "=")
+		name = strings.ToLower(strings.ReplaceAll(name, "-", ""))
+		if name == "" {
+			return "", false
+		}
+		for _, knownName := range names {
+			if strings.HasPrefix(knownName, name) {
+				return knownName, true
+			}
+		}
+		return "", false
+	}
+
+	short := option[1:2]
+	for _, knownName := range names {
+		if shortName := known[knownName]; shortName != "" && short == shortName {
+			return knownName, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,55 @@
+package suite
+
+import "testing"
+
+func TestValidateRobotArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "none"},
+		{
+			name: "pass through",
+			args: []string{"--variable", "ENV:staging", "--variablefile=vars.py", "-i", "smoke", "--exclude", "wip",
+				"--loglevel", "DEBUG", "-L", "TRACE", "--listener", "listener.py", "--pythonpath", "lib", "--dryrun"},
+		},
+		{name: "output dir", args: []string{"--outputdir", "results"}, wantErr: true},
+		{name: "output dir with value", args: []string{"--outputdir=results"}, wantErr: true},
+		{name: "case and hyphens", args: []string{"--Output-Dir", "results"}, wantErr: true},
+		{name: "prefix", args: []string{"--outp", "out.xml"}, wantErr: true},
+		{name: "short option", args: []string{"-d", "results"}, wantErr: true},
+		{name: "short option with value", args: []string{"-lmylog.html"}, wantErr: true},
+		{name: "tests", args: []string{"--test", "Login"}, wantErr: true},
+		{name: "status rc", args: []string{"--nostatusrc"}, wantErr: true},
+		{name: "argument file", args: []string{"--argumentfile", "args.txt"}, wantErr: true},
+		{name: "value starting with a hyphen", args: []string{"--variable", "-x", "--metadata", "--output:x", "-v", "-d"}},
+		{name: "value of a shortened option", args: []string{"--var", "--outputdir", "--log-level", "-L"}},
+		{name: "value given with the option", args: []string{"--variable=X:1", "-d"}, wantErr: true},
+		{name: "short option with attached value", args: []string{"-vX:1", "-o", "out.xml"}, wantErr: true},
+		{name: "flag before a controlled option", args: []string{"--dryrun", "-d", "results"}, wantErr: true},
+		{name: "double dash", args: []string{"--loglevel", "DEBUG", "--", "-d"}, wantErr: true},
+		{name: "data source", args: []string{"--include", "smoke", "extra/tests"}, wantErr: true},
+		{name: "value as the last argument", args: []string{"--variable"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRobotArgs(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRobotArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRebotArgs(t *testing.T) {
+	if err := ValidateRebotArgs([]string{"--removekeywords=passed", "--flattenkeywords", "name:Retry*"}); err != nil {
+		t.Errorf("ValidateRebotArgs() error = %v", err)
+	}
+	// values starting with a hyphen are not options
+	if err := ValidateRebotArgs([]string{"--removekeywords", "-x", "--doc", "--name"}); err != nil {
+		t.Errorf("ValidateRebotArgs() error = %v, want option values skipped", err)
+	}
+	if err := ValidateRebotArgs([]string{"--starttime", "20230801 10:00:00"}); err == nil {
+		t.Error("ValidateRebotArgs() error = nil, want a conflict with --starttime")
+	}
+}
@@ -0,0 +1,65 @@
+package suite
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// echo receives the commands executed by kubot and their output
+var echo io.Writer = os.Stdout
+
+// SetEcho sets the writer receiving the commands executed by kubot and their output, e.g. to mask credentials
+func SetEcho(w io.Writer) {
+	echo = w
+}
+
+// consoleName returns the name of the console log of the given execution attempt of a suite
+func consoleName(attempt int) string {
+	if attempt == 0 {
+		return "console.log"
+	}
+	return fmt.Sprintf("console-rerun-%d.log", attempt)
+}
+
+// prefixWriter writes complete lines into w prefixed with a name. Writers sharing a mutex
+// never interleave their lines, so the consoles of concurrent suites stay readable.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (it *prefixWriter) Write(p []byte) (int, error) {
+	it.buf = append(it.buf, p...)
+	for {
+		i := bytes.IndexByte(it.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		it.writeLine(it.buf[:i+1])
+		it.buf = it.buf[i+1:]
+	}
+}
+
+// Flush writes the last line even if it is not terminated
+func (it *prefixWriter) Flush() {
+	if len(it.buf) > 0 {
+		it.writeLine(append(it.buf, '\n'))
+		it.buf = nil
+	}
+}
+
+func (it *prefixWriter) writeLine(line []byte) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	_, _ = it.w.Write(append([]byte(it.prefix), line...))
+}
+
+func newPrefixWriter(mu *sync.Mutex, w io.Writer, name string) *prefixWriter {
+	return &prefixWriter{mu: mu, w: w, prefix: fmt.Sprintf("[%s] ", name)}
+}
@@ -0,0 +1,28 @@
+package suite
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	mu := &sync.Mutex{}
+	google := newPrefixWriter(mu, out, "google.robot")
+	users := newPrefixWriter(mu, out, "admin/users.robot")
+
+	_, _ = google.Write([]byte("Scripts.Google\nSea"))
+	_, _ = users.Write([]byte("Scripts.Admin.Users\n"))
+	_, _ = google.Write([]byte("rch | PASS |\nVisit"))
+	google.Flush()
+	users.Flush()
+
+	want := "[google.robot] Scripts.Google\n" +
+		"[admin/users.robot] Scripts.Admin.Users\n" +
+		"[google.robot] Search | PASS |\n" +
+		"[google.robot] Visit\n"
+	if got := out.String(); got != want {
+		t.Errorf("prefixWriter got = %q, want %q", got, want)
+	}
+}
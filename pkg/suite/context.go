@@ -0,0 +1,15 @@
+package suite
+
+import (
+	"context"
+	"time"
+)
+
+// cleanupTimeout bounds the time spent on collecting results and deleting resources
+const cleanupTimeout = 5 * time.Minute
+
+// CleanupContext returns a context independent of the execution context,
+// so results can be collected and resources deleted after an interruption.
+func CleanupContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), cleanupTimeout)
+}
@@ -0,0 +1,102 @@
+package suite
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	RequirementsFile = "requirements.txt"
+	PyprojectFile    = "pyproject.toml"
+
+	// venvDir is the virtualenv of the suite pods on the suite volume
+	venvDir = "/data/venv"
+)
+
+// venvArgs create a virtualenv seeing the packages of the image, including pip. Images often lack ensurepip to
+// install pip into the virtualenv itself.
+var venvArgs = []string{"venv", "--system-site-packages", "--without-pip"}
+
+// browserRequirement matches the Browser library, robotframework-browser-batteries ships its node dependencies
+var browserRequirement = regexp.MustCompile(`(?i)robotframework[-_.]browser($|[^-_.a-z0-9])`)
+
+// ownBrowserCheck exits with 0 when the Browser library is installed into the virtualenv itself, a Browser library
+// of the image is initialized already and may not be writable
+const ownBrowserCheck = "import sys, Browser; sys.exit(not Browser.__file__.startswith(sys.prefix))"
+
+// Dependencies are the Python dependencies of the workspace, installed into a virtualenv before the suites run.
+// The virtualenv sees the packages of the image, so robot and the libraries of the image are not installed again.
+type Dependencies struct {
+	file    string // requirements.txt or pyproject.toml in the workspace root
+	browser bool   // the Browser library downloads its node dependencies and browsers with rfbrowser init
+}
+
+// File returns the name of the dependency file
+func (it *Dependencies) File() string {
+	return it.file
+}
+
+// installArgs returns the pip install arguments of the dependency file, pip is run in the workspace root
+// so relative paths in the requirements work
+func (it *Dependencies) installArgs() []string {
+	if it.file == PyprojectFile {
+		return []string{"install", "--no-input", "."}
+	}
+	return []string{"install", "--no-input", "-r", it.file}
+}
+
+// installScript returns the shell script installing the dependencies of the workspace into the virtualenv
+func (it *Dependencies) installScript(workspaceDir string) string {
+	python := path.Join(venvDir, "bin", "python")
+
+	script := []string{
+		fmt.Sprintf("python3 -m %s %s", strings.Join(venvArgs, " "), venvDir),
+		fmt.Sprintf("cd %s", shellQuote(workspaceDir)),
+		fmt.Sprintf("%s -m pip %s", python, strings.Join(it.installArgs(), " ")),
+	}
+	if it.browser {
+		script = append(script, fmt.Sprintf("if %s -c %s; then %s -m Browser.entry init; fi", python, shellQuote(ownBrowserCheck), python))
+	}
+
+	return strings.Join(script, " && ")
+}
+
+// wrap runs the robot command with the python of the virtualenv, robot of the image would not see the installed packages
+func (it *Dependencies) wrap(cmd []string) []string {
+	if it == nil || len(cmd) == 0 || cmd[0] != "robot" {
+		return cmd
+	}
+
+	activate := fmt.Sprintf(`. %s && exec python -m robot "$@"`, path.Join(venvDir, "bin", "activate"))
+	return append([]string{"sh", "-c", activate, "robot"}, cmd[1:]...)
+}
+
+// shellQuote quotes the value for sh, the workspace directory is named after the local one
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// DetectDependencies looks for requirements.txt, then pyproject.toml in the workspace root. It returns nil when
+// the workspace has neither.
+func DetectDependencies(root string) (*Dependencies, error) {
+	for _, file := range []string{RequirementsFile, PyprojectFile} {
+		content, err := os.ReadFile(filepath.Join(root, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read dependencies: %w", err)
+		}
+
+		return &Dependencies{
+			file:    file,
+			browser: browserRequirement.Match(content),
+		}, nil
+	}
+
+	return nil, nil
+}
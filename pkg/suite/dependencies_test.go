@@ -0,0 +1,78 @@
+package suite
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectDependencies(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantFile    string
+		wantBrowser bool
+	}{
+		{name: "none", files: map[string]string{}},
+		{name: "requirements", files: map[string]string{"requirements.txt": "robotframework-requests==0.9.5\n"}, wantFile: RequirementsFile},
+		{name: "browser", files: map[string]string{"requirements.txt": "robotframework-Browser>=17\n"}, wantFile: RequirementsFile, wantBrowser: true},
+		{name: "browser batteries", files: map[string]string{"requirements.txt": "robotframework-browser-batteries\n"}, wantFile: RequirementsFile},
+		{name: "pyproject", files: map[string]string{"pyproject.toml": "dependencies = [\"robotframework_browser\"]\n"}, wantFile: PyprojectFile, wantBrowser: true},
+		{name: "requirements first", files: map[string]string{"requirements.txt": "", "pyproject.toml": ""}, wantFile: RequirementsFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			deps, err := DetectDependencies(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantFile == "" {
+				if deps != nil {
+					t.Errorf("DetectDependencies() got = %+v, want nil", deps)
+				}
+				return
+			}
+			if deps == nil || deps.File() != tt.wantFile || deps.browser != tt.wantBrowser {
+				t.Errorf("DetectDependencies() got = %+v, want %s with browser %v", deps, tt.wantFile, tt.wantBrowser)
+			}
+		})
+	}
+}
+
+func TestDependencies_InstallScript(t *testing.T) {
+	deps := &Dependencies{file: RequirementsFile, browser: true}
+
+	script := deps.installScript("/data/workspace/it's scripts")
+	for _, want := range []string{
+		"python3 -m venv --system-site-packages --without-pip /data/venv",
+		`cd '/data/workspace/it'\''s scripts'`,
+		"/data/venv/bin/python -m pip install --no-input -r requirements.txt",
+		"then /data/venv/bin/python -m Browser.entry init; fi",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("installScript() got = %s, want %s in it", script, want)
+		}
+	}
+}
+
+func TestDependencies_Wrap(t *testing.T) {
+	var none *Dependencies
+	if got := none.wrap([]string{"robot", "--outputdir", "out"}); !reflect.DeepEqual(got, []string{"robot", "--outputdir", "out"}) {
+		t.Errorf("wrap() without dependencies got = %v", got)
+	}
+
+	deps := &Dependencies{file: RequirementsFile}
+	want := []string{"sh", "-c", `. /data/venv/bin/activate && exec python -m robot "$@"`, "robot", "--outputdir", "out"}
+	if got := deps.wrap([]string{"robot", "--outputdir", "out"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrap() got = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+package suite
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/yusufcanb/kubot/pkg/cluster"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"path"
+	"sort"
+	"strings"
+)
+
+// EnvOptions selects the environment variables of the robot container, nothing is copied from the host unless asked
+type EnvOptions struct {
+	Vars       []string // KEY=VALUE pairs, values are passed as is
+	FromHost   []string // glob patterns of the host variables to copy, e.g. CI_* or API_URL
+	Secrets    []string // names of the secrets whose keys become variables
+	ConfigMaps []string // names of the config maps whose keys become variables
+}
+
+// Env is the resolved environment of the robot container
+type Env struct {
+	vars    []corev1.EnvVar
+	envFrom []corev1.EnvFromSource
+}
+
+// Vars returns the variables as KEY=VALUE pairs
+func (it *Env) Vars() []string {
+	if it == nil {
+		return nil
+	}
+
+	vars := make([]string, 0, len(it.vars))
+	for _, v := range it.vars {
+		vars = append(vars, v.Name+"="+v.Value)
+	}
+	return vars
+}
+
+// secretNameParts are the parts of the variable names holding credentials, e.g. API_TOKEN or DB_PASSWORD
+var secretNameParts = map[string]bool{
+	"PASSWORD": true, "PASSWD": true, "SECRET": true, "TOKEN": true, "KEY": true,
+	"APIKEY": true, "CREDENTIAL": true, "CREDENTIALS": true, "AUTH": true, "PRIVATE": true,
+}
+
+// SecretValues returns the values of the variables named like credentials, they are masked in the logs.
+// Other values, e.g. true, prod or 8080, would mask unrelated text.
+func (it *Env) SecretValues() []string {
+	if it == nil {
+		return nil
+	}
+
+	values := make([]string, 0)
+	for _, v := range it.vars {
+		for _, part := range strings.Split(strings.ToUpper(v.Name), "_") {
+			if secretNameParts[part] {
+				values = append(values, v.Value)
+				break
+			}
+		}
+	}
+	return values
+}
+
+// Secrets returns the names of the referenced secrets
+func (it *Env) Secrets() []string {
+	return it.sources(func(source corev1.EnvFromSource) string {
+		if source.SecretRef == nil {
+			return ""
+		}
+		return source.SecretRef.Name
+	})
+}
+
+// ConfigMaps returns the names of the referenced config maps
+func (it *Env) ConfigMaps() []string {
+	return it.sources(func(source corev1.EnvFromSource) string {
+		if source.ConfigMapRef == nil {
+			return ""
+		}
+		return source.ConfigMapRef.Name
+	})
+}
+
+func (it *Env) sources(name func(corev1.EnvFromSource) string) []string {
+	if it == nil {
+		return nil
+	}
+
+	names := make([]string, 0)
+	for _, source := range it.envFrom {
+		if n := name(source); n != "" {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// Preflight checks the referenced secrets and config maps exist, pods referencing a missing one would never start.
+// They are not checked when kubot is not allowed to read them.
+func (it *Env) Preflight(ctx context.Context, c *cluster.Cluster) error {
+	namespace := c.DefaultNamespace()
+
+	for _, name := range it.Secrets() {
+		_, err := c.Client().CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err := envSourceError(err, "secret", namespace, name); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range it.ConfigMaps() {
+		_, err := c.Client().CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err := envSourceError(err, "config map", namespace, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func envSourceError(err error, kind string, namespace string, name string) error {
+	switch {
+	case err == nil:
+		return nil
+	case apierrors.IsNotFound(err):
+		return fmt.Errorf("%s %s/%s of the environment variables does not exist", kind, namespace, name)
+	case apierrors.IsForbidden(err):
+		log.Warnf("cannot check %s %s/%s exists: %s", kind, namespace, name, err)
+		return nil
+	default:
+		return fmt.Errorf("check %s %s/%s: %w", kind, namespace, name, err)
+	}
+}
+
+// NewEnv resolves the options against the host environment, e.g. os.Environ(). Variables given with --env
+// take precedence over the host ones.
+func NewEnv(options EnvOptions, environ []string) (*Env, error) {
+	values := make(map[string]string)
+
+	for _, pattern := range options.FromHost {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid host variable pattern %q: %v", pattern, err)
+		}
+
+		for _, variable := range environ {
+			name, value, found := strings.Cut(variable, "=")
+			if !found || name == "" {
+				continue
+			}
+			if matched, _ := path.Match(pattern, name); matched && len(validation.IsEnvVarName(name)) == 0 {
+				values[name] = value
+			}
+		}
+	}
+
+	for _, variable := range options.Vars {
+		name, value, found := strings.Cut(variable, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid variable %q, use KEY=VALUE", variable)
+		}
+		if errs := validation.IsEnvVarName(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid variable name %q: %s", name, strings.Join(errs, ", "))
+		}
+		values[name] = value
+	}
+
+	env := &Env{}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env.vars = append(env.vars, corev1.EnvVar{Name: name, Value: values[name]})
+	}
+
+	for _, name := range options.Secrets {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid secret name %q: %s", name, strings.Join(errs, ", "))
+		}
+		env.envFrom = append(env.envFrom, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+		})
+	}
+
+	for _, name := range options.ConfigMaps {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid config map name %q: %s", name, strings.Join(errs, ", "))
+		}
+		env.envFrom = append(env.envFrom, corev1.EnvFromSource{
+			ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+		})
+	}
+
+	return env, nil
+}
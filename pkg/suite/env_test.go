@@ -0,0 +1,114 @@
+package suite
+
+import (
+	"context"
+	"github.com/yusufcanb/kubot/pkg/cluster/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+func TestNewEnv(t *testing.T) {
+	environ := []string{"CI_JOB_ID=42", "CI_COMMIT_MESSAGE=fix: retry login, again", "AWS_SECRET_ACCESS_KEY=s3cr3t", "BASE_URL=https://example.com/?a=b"}
+
+	tests := []struct {
+		name    string
+		options EnvOptions
+		want    []string
+		wantErr bool
+	}{
+		{name: "nothing from the host by default", options: EnvOptions{}, want: []string{}},
+		{
+			name:    "host patterns",
+			options: EnvOptions{FromHost: []string{"CI_*", "BASE_URL"}},
+			want:    []string{"BASE_URL=https://example.com/?a=b", "CI_COMMIT_MESSAGE=fix: retry login, again", "CI_JOB_ID=42"},
+		},
+		{
+			name:    "explicit values override the host",
+			options: EnvOptions{FromHost: []string{"CI_JOB_ID"}, Vars: []string{"CI_JOB_ID=7", "GREETING=hello world", "EMPTY="}},
+			want:    []string{"CI_JOB_ID=7", "EMPTY=", "GREETING=hello world"},
+		},
+		{name: "missing value", options: EnvOptions{Vars: []string{"GREETING"}}, wantErr: true},
+		{name: "invalid name", options: EnvOptions{Vars: []string{"HELLO WORLD=1"}}, wantErr: true},
+		{name: "invalid pattern", options: EnvOptions{FromHost: []string{"CI_["}}, wantErr: true},
+		{name: "invalid secret", options: EnvOptions{Secrets: []string{"Robot Secrets"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := NewEnv(tt.options, environ)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(env.Vars(), tt.want) {
+				t.Errorf("Vars() got = %v, want %v", env.Vars(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEnv_SecretValues(t *testing.T) {
+	environ := []string{"AWS_SECRET_ACCESS_KEY=s3cr3t", "CI_JOB_TOKEN=t0k3n", "KEYBOARD=qwerty", "PWD=/home/ci/build", "PASS=1"}
+	env, err := NewEnv(EnvOptions{
+		FromHost: []string{"AWS_*", "CI_*", "KEYBOARD", "PWD", "PASS"},
+		Vars:     []string{"DB_Password=hunter22", "ENV=prod", "PORT=8080", "HEADLESS=true"},
+	}, environ)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// masking ordinary settings would blank out unrelated log text
+	want := []string{"s3cr3t", "t0k3n", "hunter22"}
+	if got := env.SecretValues(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretValues() got = %v, want %v", got, want)
+	}
+}
+
+func TestEnv_Preflight(t *testing.T) {
+	c, client, _ := fake.NewCluster("kubot")
+	ctx := context.Background()
+	_, _ = client.CoreV1().Secrets("kubot").Create(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "robot-credentials"}}, metav1.CreateOptions{})
+
+	env, err := NewEnv(EnvOptions{Secrets: []string{"robot-credentials"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.Preflight(ctx, c); err != nil {
+		t.Errorf("Preflight() error = %v", err)
+	}
+
+	env, err = NewEnv(EnvOptions{Secrets: []string{"robot-credentials"}, ConfigMaps: []string{"robot-settings"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.Preflight(ctx, c); err == nil {
+		t.Error("Preflight() error = nil, want a missing config map")
+	}
+}
+
+func TestNewSuiteJob_Env(t *testing.T) {
+	env, err := NewEnv(EnvOptions{Vars: []string{"BASE_URL=https://example.com"}, Secrets: []string{"robot-credentials"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, _, _ := fake.NewCluster("kubot")
+	ctx := context.Background()
+	v, err := NewVolume(ctx, c, "run-1", VolumeOptions{Size: "1Gi", Pods: PodOptions{Env: env}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suiteJob, err := NewSuiteJob(ctx, v, "robot", "google.robot", []string{"robot"}, JobOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	container := suiteJob.job.Spec.Template.Spec.Containers[0]
+	if len(container.Env) != 1 || container.Env[0].Value != "https://example.com" {
+		t.Errorf("container env got = %v", container.Env)
+	}
+	if len(container.EnvFrom) != 1 || container.EnvFrom[0].SecretRef.Name != "robot-credentials" {
+		t.Errorf("container envFrom got = %v", container.EnvFrom)
+	}
+}
@@ -0,0 +1,56 @@
+package suite
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	BackendKubernetes = "kubernetes" // suites run as jobs in the cluster
+	BackendLocal      = "local"      // suites run as robot processes on the local machine
+)
+
+var (
+	// ErrTimedOut is returned when robot is stopped at the suite timeout, its output has the results of the tests run so far
+	ErrTimedOut = errors.New("suite has timed out")
+	// ErrTimedOutKilled is returned when robot has not stopped at the suite timeout and is killed without an output
+	ErrTimedOutKilled = errors.New("suite has timed out and was killed")
+	// ErrInterrupted is returned when robot is stopped gracefully at an interruption, its output has the results of
+	// the tests run so far
+	ErrInterrupted = errors.New("suite has been interrupted")
+)
+
+// timeoutGracePeriod is the time robot is given to stop and write its output at the suite timeout or an interruption
+var timeoutGracePeriod = 2 * time.Minute
+
+// Execution is a robot command to run for a suite
+type Execution struct {
+	Suite   string
+	Cmd     []string
+	Console io.Writer     // receives the robot console
+	Timeout time.Duration // robot is stopped once the timeout passes, 0 means no timeout
+	Profile string        // resource profile of the suite pods, the default profile when empty
+}
+
+// Executor runs the robot commands of the suites and merges their outputs.
+// Output paths passed to an executor are relative to its output root, e.g. admin/users.robot/output.xml
+type Executor interface {
+	// WorkspaceDir returns the path of the workspace root robot is executed against
+	WorkspaceDir() string
+	// OutputRoot returns the directory the suite output directories are created in
+	OutputRoot() string
+	// Execute runs the robot command of the suite. ErrTestsFailed is returned when robot has failed tests,
+	// ErrTimedOut or ErrTimedOutKilled when robot is stopped at the timeout, ErrInterrupted when robot has stopped
+	// gracefully after the context is cancelled.
+	Execute(ctx context.Context, e Execution) error
+	// RerunArgs returns the robot arguments selecting the failed tests of the given output
+	RerunArgs(output string) ([]string, error)
+	// Merge runs rebot with the given options over the outputs and stores the merged results in LocalOutputDir
+	Merge(ctx context.Context, options []string, outputs []string) error
+	// Phases returns the phases of the pods of the running suites by suite name, nil when the suites run without pods
+	Phases(ctx context.Context) (map[string]string, error)
+	// Clean stops the running suites
+	Clean()
+}
@@ -0,0 +1,336 @@
+package suite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/yusufcanb/kubot/pkg/cluster"
+	"io"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"sort"
+	"time"
+)
+
+// jobReasonPodFailurePolicy is the reason of the Failed condition when the pod failure policy fails the job
+const jobReasonPodFailurePolicy = "PodFailurePolicy"
+
+// ErrTestsFailed is returned when robot has run the suite and some of its tests have failed
+var ErrTestsFailed = errors.New("suite has failed tests")
+
+// JobOptions configures the Job objects created for the suites
+type JobOptions struct {
+	BackoffLimit            int32 // retries on infrastructure failures, failed tests are never retried
+	ActiveDeadlineSeconds   int64 // 0 means no deadline
+	TTLSecondsAfterFinished int32 // negative means finished jobs are kept
+
+	ResourceProfile string // resources of the robot container, the default profile when empty
+}
+
+type Job struct {
+	cluster *cluster.Cluster
+	job     *batchv1.Job
+}
+
+// robotStoppedExitCode is the robot return code when it is stopped by a signal, e.g. at the suite timeout
+const robotStoppedExitCode = 253
+
+// errRobotStopped is returned when robot has been stopped by a signal and has written its output
+var errRobotStopped = errors.New("robot was stopped")
+
+// robotFailureExitCodes are the robot return codes meaning the suite was executed but tests failed,
+// along with 252 (invalid data or options) which would fail again on every retry and 253 (stopped by kubot).
+func robotFailureExitCodes() []int32 {
+	codes := make([]int32, 0, 252)
+	for code := int32(1); code <= 250; code++ {
+		codes = append(codes, code)
+	}
+	return append(codes, 252, robotStoppedExitCode)
+}
+
+// wait watches the job until it is complete or failed
+func (it *Job) wait(ctx context.Context) error {
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", it.job.Name).String()
+	jobs := it.cluster.Client().BatchV1().Jobs(it.job.Namespace)
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return jobs.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return jobs.Watch(ctx, options)
+		},
+	}
+
+	var failed *batchv1.JobCondition
+	_, err := watchtools.UntilWithSync(ctx, lw, &batchv1.Job{}, nil, func(event watch.Event) (bool, error) {
+		if event.Type == watch.Deleted {
+			return false, fmt.Errorf("job %s/%s was deleted before it has finished", it.job.Namespace, it.job.Name)
+		}
+
+		job, ok := event.Object.(*batchv1.Job)
+		if !ok || job.Name != it.job.Name {
+			return false, nil
+		}
+
+		for i, condition := range job.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case batchv1.JobComplete:
+				return true, nil
+			case batchv1.JobFailed:
+				failed = &job.Status.Conditions[i]
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if failed == nil {
+		return nil
+	}
+
+	if failed.Reason == jobReasonPodFailurePolicy {
+		return it.robotFailure(ctx)
+	}
+
+	return fmt.Errorf("job %s/%s failed: %s: %s", it.job.Namespace, it.job.Name, failed.Reason, failed.Message)
+}
+
+// robotFailure tells failed tests apart from robot errors by the exit code of the job's pod
+func (it *Job) robotFailure(ctx context.Context) error {
+	pods, err := it.pods(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if status.Name != containerName || terminated == nil || terminated.ExitCode == 0 {
+				continue
+			}
+			if terminated.ExitCode == robotStoppedExitCode && terminated.Reason != "OOMKilled" {
+				return fmt.Errorf("job %s/%s failed: %w", it.job.Namespace, it.job.Name, errRobotStopped)
+			}
+			if terminated.Reason == "OOMKilled" || terminated.ExitCode > 250 {
+				return fmt.Errorf("job %s/%s failed: robot exited with code %d (%s)", it.job.Namespace, it.job.Name, terminated.ExitCode, terminated.Reason)
+			}
+		}
+	}
+
+	return ErrTestsFailed
+}
+
+// pods returns the pods created for the job
+func (it *Job) pods(ctx context.Context) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(it.job.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := it.cluster.Client().CoreV1().Pods(it.job.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods of job %s/%s: %v", it.job.Namespace, it.job.Name, err)
+	}
+
+	return pods.Items, nil
+}
+
+// logPollInterval is the interval the pods of a job are listed at while streaming their logs
+var logPollInterval = 2 * time.Second
+
+// streamLogs follows the robot container logs of the job pods into w, a pod after the other as the job retries.
+// It returns when finished is closed and the logs of every started pod are streamed, or the context is cancelled.
+func (it *Job) streamLogs(ctx context.Context, w io.Writer, finished <-chan struct{}) {
+	streamed := make(map[string]bool)
+	for {
+		done := false
+		select {
+		case <-finished:
+			done = true
+		default:
+		}
+
+		pods, err := it.pods(ctx)
+		if err != nil {
+			log.Debugf("streaming logs: %s", err)
+		}
+		sort.Slice(pods, func(i, j int) bool {
+			return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+		})
+
+		for _, pod := range pods {
+			if streamed[pod.Name] || !containerStarted(pod, containerName) {
+				continue
+			}
+			if len(streamed) > 0 {
+				_, _ = fmt.Fprintf(w, "### retried in pod %s\n", pod.Name)
+			}
+			streamed[pod.Name] = true
+
+			if err := it.followLogs(ctx, pod, w); err != nil && ctx.Err() == nil {
+				log.Debugf("streaming logs of pod %s/%s: %s", pod.Namespace, pod.Name, err)
+			}
+		}
+
+		if done {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-finished:
+		case <-time.After(logPollInterval):
+		}
+	}
+}
+
+// followLogs copies the logs of the robot container into w until the container terminates
+func (it *Job) followLogs(ctx context.Context, pod corev1.Pod, w io.Writer) error {
+	stream, err := it.cluster.Client().CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: containerName,
+		Follow:    true,
+	}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	_, err = io.Copy(w, stream)
+	return err
+}
+
+// stop terminates robot in the running pods of the job, so it stops gracefully and writes its output
+func (it *Job) stop(ctx context.Context) {
+	pods, err := it.pods(ctx)
+	if err != nil {
+		log.Errorf("stopping job %s/%s: %s", it.job.Namespace, it.job.Name, err)
+		return
+	}
+
+	for i := range pods {
+		if pods[i].Status.Phase != corev1.PodRunning {
+			continue
+		}
+		// robot is the main process of the container
+		err = it.cluster.Executor().Exec(ctx, &pods[i], containerName, []string{"sh", "-c", "kill -TERM 1"}, cluster.Streams{})
+		if err != nil {
+			log.Errorf("stopping robot in pod %s/%s: %s", pods[i].Namespace, pods[i].Name, err)
+		}
+	}
+}
+
+// containerStarted reports whether the container of the pod is running or has run, so it has logs
+func containerStarted(pod corev1.Pod, name string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == name {
+			return status.State.Running != nil || status.State.Terminated != nil
+		}
+	}
+	return false
+}
+
+// destroy the job along with its pods
+func (it *Job) destroy(ctx context.Context) error {
+	if it.job == nil {
+		return nil
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	err := it.cluster.Client().BatchV1().Jobs(it.job.Namespace).Delete(ctx, it.job.Name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil {
+		return err
+	}
+
+	it.job = nil
+
+	return nil
+}
+
+// NewSuiteJob creates a job running the given robot command to completion
+func NewSuiteJob(ctx context.Context, t Transfer, image string, suiteName string, command []string, options JobOptions) (*Job, error) {
+	suiteJob := Job{}
+	suiteJob.cluster = t.Cluster()
+
+	podSpec, err := newPodSpec(t, image, command, ComponentSuite, options.ResourceProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	container := containerName
+	spec := batchv1.JobSpec{
+		BackoffLimit: &options.BackoffLimit,
+		PodFailurePolicy: &batchv1.PodFailurePolicy{
+			Rules: []batchv1.PodFailurePolicyRule{
+				{
+					// evicted or preempted pods are retried without counting towards the backoff limit
+					Action: batchv1.PodFailurePolicyActionIgnore,
+					OnPodConditions: []batchv1.PodFailurePolicyOnPodConditionsPattern{
+						{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue},
+					},
+				},
+				{
+					// robot has run the suite, retrying would only repeat the failed tests
+					Action: batchv1.PodFailurePolicyActionFailJob,
+					OnExitCodes: &batchv1.PodFailurePolicyOnExitCodesRequirement{
+						ContainerName: &container,
+						Operator:      batchv1.PodFailurePolicyOnExitCodesOpIn,
+						Values:        robotFailureExitCodes(),
+					},
+				},
+			},
+		},
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      resourceLabels(t.RunID(), ComponentSuite, suiteName),
+				Annotations: map[string]string{AnnotationSuite: suiteName},
+			},
+			Spec: podSpec,
+		},
+	}
+
+	if options.ActiveDeadlineSeconds > 0 {
+		spec.ActiveDeadlineSeconds = &options.ActiveDeadlineSeconds
+	}
+
+	if options.TTLSecondsAfterFinished >= 0 {
+		spec.TTLSecondsAfterFinished = &options.TTLSecondsAfterFinished
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName:    "kubot-",
+			Namespace:       suiteJob.cluster.DefaultNamespace(),
+			Labels:          resourceLabels(t.RunID(), ComponentSuite, suiteName),
+			Annotations:     map[string]string{AnnotationSuite: suiteName},
+			OwnerReferences: t.ownerReferences(),
+		},
+		Spec: spec,
+	}
+
+	job, err = suiteJob.cluster.Client().BatchV1().Jobs(suiteJob.cluster.DefaultNamespace()).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	suiteJob.job = job
+
+	return &suiteJob, nil
+}
@@ -0,0 +1,171 @@
+package suite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/yusufcanb/kubot/pkg/cluster/fake"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestJob_Wait(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition batchv1.JobCondition
+		exitCode  int32
+		reason    string
+		wantErr   error // nil, ErrTestsFailed, errRobotStopped or errInfrastructure
+	}{
+		{
+			name:      "complete",
+			condition: batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+		},
+		{
+			name:      "failed tests",
+			condition: batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: jobReasonPodFailurePolicy},
+			exitCode:  3,
+			wantErr:   ErrTestsFailed,
+		},
+		{
+			name:      "invalid robot data",
+			condition: batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: jobReasonPodFailurePolicy},
+			exitCode:  252,
+			wantErr:   errInfrastructure,
+		},
+		{
+			name:      "stopped at the timeout",
+			condition: batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: jobReasonPodFailurePolicy},
+			exitCode:  robotStoppedExitCode,
+			wantErr:   errRobotStopped,
+		},
+		{
+			name:      "out of memory",
+			condition: batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: jobReasonPodFailurePolicy},
+			exitCode:  137,
+			reason:    "OOMKilled",
+			wantErr:   errInfrastructure,
+		},
+		{
+			name:      "backoff limit exceeded",
+			condition: batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"},
+			wantErr:   errInfrastructure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, client, _ := fake.NewCluster("kubot")
+			ctx := context.Background()
+
+			v, err := NewVolume(ctx, c, "run-1", VolumeOptions{Size: "1Gi"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			suiteJob, err := NewSuiteJob(ctx, v, "robot", "admin/users.robot", []string{"robot"}, JobOptions{BackoffLimit: 2, TTLSecondsAfterFinished: -1})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			job := suiteJob.job.DeepCopy()
+			if job.Labels[LabelSuite] != "admin.users.robot" || job.Annotations[AnnotationSuite] != "admin/users.robot" || job.Spec.TTLSecondsAfterFinished != nil {
+				t.Errorf("job got = %+v", job.ObjectMeta)
+			}
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: job.Name + "-pod", Namespace: "kubot", Labels: job.Spec.Template.Labels},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name: containerName,
+							State: corev1.ContainerState{
+								Terminated: &corev1.ContainerStateTerminated{ExitCode: tt.exitCode, Reason: tt.reason},
+							},
+						},
+					},
+				},
+			}
+			if _, err := client.CoreV1().Pods("kubot").Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+
+			job.Status.Conditions = []batchv1.JobCondition{tt.condition}
+			if _, err := client.BatchV1().Jobs("kubot").UpdateStatus(ctx, job, metav1.UpdateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+
+			err = suiteJob.wait(ctx)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Errorf("wait() error = %v, want nil", err)
+			case tt.wantErr == ErrTestsFailed && !errors.Is(err, ErrTestsFailed):
+				t.Errorf("wait() error = %v, want %v", err, ErrTestsFailed)
+			case tt.wantErr == errRobotStopped && !errors.Is(err, errRobotStopped):
+				t.Errorf("wait() error = %v, want %v", err, errRobotStopped)
+			case tt.wantErr == errInfrastructure && (err == nil || errors.Is(err, ErrTestsFailed) || errors.Is(err, errRobotStopped)):
+				t.Errorf("wait() error = %v, want an infrastructure error", err)
+			}
+
+			if err := suiteJob.destroy(ctx); err != nil {
+				t.Fatal(err)
+			}
+			jobs, _ := client.BatchV1().Jobs("kubot").List(ctx, metav1.ListOptions{})
+			if len(jobs.Items) != 0 {
+				t.Errorf("jobs got = %d after destroy(), want 0", len(jobs.Items))
+			}
+		})
+	}
+}
+
+// errInfrastructure marks the cases failing with an error other than ErrTestsFailed
+var errInfrastructure = errors.New("infrastructure error")
+
+func TestJob_StreamLogs(t *testing.T) {
+	c, client, _ := fake.NewCluster("kubot")
+	ctx := context.Background()
+
+	v, err := NewVolume(ctx, c, "run-1", VolumeOptions{Size: "1Gi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suiteJob, err := NewSuiteJob(ctx, v, "robot", "google.robot", []string{"robot"}, JobOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := suiteJob.job.Spec.Template.Labels
+	terminated := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137}}
+	pods := []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "first", Namespace: "kubot", Labels: labels, CreationTimestamp: metav1.Unix(1, 0)},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: containerName, State: terminated}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "retry", Namespace: "kubot", Labels: labels, CreationTimestamp: metav1.Unix(2, 0)},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: containerName, State: terminated}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "kubot", Labels: labels, CreationTimestamp: metav1.Unix(3, 0)},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+	}
+	for _, pod := range pods {
+		if err := client.Tracker().Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	finished := make(chan struct{})
+	close(finished)
+
+	console := &bytes.Buffer{}
+	suiteJob.streamLogs(ctx, console, finished)
+
+	// the fake clientset serves "fake logs" for every container
+	if got, want := console.String(), "fake logs### retried in pod retry\nfake logs"; got != want {
+		t.Errorf("streamLogs() got = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+package suite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// logDrainTimeout is the time the logs of a finished suite are awaited for
+var logDrainTimeout = 30 * time.Second
+
+// KubernetesExecutor runs every suite as a job in the cluster, the workspace and the outputs are shipped by the transfer
+type KubernetesExecutor struct {
+	transfer   Transfer
+	image      string
+	jobOptions JobOptions
+
+	mu   sync.Mutex
+	jobs []*Job // jobs created by the run
+}
+
+func (it *KubernetesExecutor) WorkspaceDir() string {
+	return it.transfer.WorkspaceDir()
+}
+
+func (it *KubernetesExecutor) OutputRoot() string {
+	return outputRoot
+}
+
+// Execute creates the job of the suite and waits for it, the robot container logs of its pods are followed into the console.
+// At the timeout, robot is terminated to stop gracefully and write its output, the job is deleted after the grace period.
+func (it *KubernetesExecutor) Execute(ctx context.Context, e Execution) error {
+	jobOptions := it.jobOptions
+	jobOptions.ResourceProfile = e.Profile
+
+	cmd := it.transfer.podOptions().Dependencies.wrap(e.Cmd)
+	suiteJob, err := NewSuiteJob(ctx, it.transfer, it.image, e.Suite, cmd, jobOptions)
+	if err != nil {
+		return err
+	}
+	log.Debugf("%s >>> %s", suiteJob.job.Name, cmd)
+
+	it.mu.Lock()
+	it.jobs = append(it.jobs, suiteJob)
+	it.mu.Unlock()
+
+	logCtx, cancelLogs := context.WithCancel(ctx)
+	defer cancelLogs()
+
+	finished, streamed := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(streamed)
+		suiteJob.streamLogs(logCtx, e.Console, finished)
+	}()
+
+	waitCtx, cancelWait := context.WithCancel(ctx)
+	defer cancelWait()
+
+	var timedOut int32
+	if e.Timeout > 0 {
+		go func() {
+			select {
+			case <-finished:
+				return
+			case <-waitCtx.Done():
+				return
+			case <-time.After(e.Timeout):
+			}
+
+			log.Warnf("suite %s has timed out after %s, stopping robot", e.Suite, e.Timeout)
+			atomic.StoreInt32(&timedOut, 1)
+			suiteJob.stop(waitCtx)
+
+			select {
+			case <-finished:
+			case <-waitCtx.Done():
+			case <-time.After(timeoutGracePeriod):
+				log.Warnf("robot has not stopped in %s, killing suite %s", timeoutGracePeriod, e.Suite)
+				cancelWait()
+			}
+		}()
+	}
+
+	err = it.transfer.wait(waitCtx, suiteJob, e.Suite)
+
+	// the logs of terminated containers end right away, running ones are not awaited for long
+	close(finished)
+	select {
+	case <-streamed:
+	case <-time.After(logDrainTimeout):
+		cancelLogs()
+		<-streamed
+	}
+
+	if atomic.LoadInt32(&timedOut) == 0 || err == nil || ctx.Err() != nil {
+		return err
+	}
+	if errors.Is(err, ErrTestsFailed) || errors.Is(err, errRobotStopped) {
+		return fmt.Errorf("%w after %s", ErrTimedOut, e.Timeout)
+	}
+
+	cleanupCtx, cancel := CleanupContext()
+	defer cancel()
+	if destroyErr := suiteJob.destroy(cleanupCtx); destroyErr != nil {
+		log.Errorf("failed to delete job of suite %s: %s", e.Suite, destroyErr)
+	}
+
+	return fmt.Errorf("%w after %s: %v", ErrTimedOutKilled, e.Timeout, err)
+}
+
+func (it *KubernetesExecutor) RerunArgs(output string) ([]string, error) {
+	return it.transfer.rerunArgs(output)
+}
+
+func (it *KubernetesExecutor) Merge(ctx context.Context, options []string, outputs []string) error {
+	return it.transfer.merge(ctx, it.image, options, outputs)
+}
+
+// Phases returns the phases of the pods of the running suites, a rerun suite has no phase until its new pod is created
+func (it *KubernetesExecutor) Phases(ctx context.Context) (map[string]string, error) {
+	c := it.transfer.Cluster()
+	pods, err := c.Client().CoreV1().Pods(c.DefaultNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s,%s=%s", LabelRunID, it.transfer.RunID(), LabelComponent, ComponentSuite),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	phases := make(map[string]string)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodPending && pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		phases[pod.Annotations[AnnotationSuite]] = string(pod.Status.Phase)
+	}
+
+	return phases, nil
+}
+
+// Clean deletes every job created by the run along with their pods
+func (it *KubernetesExecutor) Clean() {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	ctx, cancel := CleanupContext()
+	defer cancel()
+
+	for _, suiteJob := range it.jobs {
+		if suiteJob.job == nil {
+			continue
+		}
+		name := suiteJob.job.Name
+		if err := suiteJob.destroy(ctx); err != nil {
+			log.Errorf("failed to delete job %s: %s", name, err)
+		}
+	}
+}
+
+func NewKubernetesExecutor(t Transfer, image string, jobOptions JobOptions) *KubernetesExecutor {
+	return &KubernetesExecutor{
+		transfer:   t,
+		image:      image,
+		jobOptions: jobOptions,
+	}
+}
@@ -0,0 +1,55 @@
+package suite
+
+import (
+	"context"
+	"github.com/yusufcanb/kubot/pkg/cluster/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+func TestKubernetesExecutor_Phases(t *testing.T) {
+	c, client, _ := fake.NewCluster("kubot")
+	ctx := context.Background()
+
+	v, err := NewVolume(ctx, c, "run-1", VolumeOptions{Size: "1Gi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pods := []struct {
+		runID string
+		suite string
+		phase corev1.PodPhase
+	}{
+		{runID: "run-1", suite: "google.robot", phase: corev1.PodRunning},
+		{runID: "run-1", suite: "admin/users.robot", phase: corev1.PodPending},
+		{runID: "run-1", suite: "admin/roles.robot", phase: corev1.PodFailed},
+		{runID: "run-2", suite: "other.robot", phase: corev1.PodRunning},
+	}
+	for _, p := range pods {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        suiteLabelValue(p.suite),
+				Namespace:   "kubot",
+				Labels:      resourceLabels(p.runID, ComponentSuite, p.suite),
+				Annotations: map[string]string{AnnotationSuite: p.suite},
+			},
+			Status: corev1.PodStatus{Phase: p.phase},
+		}
+		if err := client.Tracker().Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	phases, err := NewKubernetesExecutor(v, "robot", JobOptions{}).Phases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"google.robot": "Running", "admin/users.robot": "Pending"}
+	if !reflect.DeepEqual(phases, want) {
+		t.Errorf("Phases() got = %v, want %v", phases, want)
+	}
+}
@@ -0,0 +1,68 @@
+package suite
+
+import (
+	"fmt"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	LabelManagedBy  = "app.kubernetes.io/managed-by"
+	LabelComponent  = "app.kubernetes.io/component"
+	LabelRunID      = "kubot.io/run-id"
+	LabelSuite      = "kubot.io/suite"
+	AnnotationSuite = "kubot.io/suite" // the suite path, labels only keep a sanitized form of it
+
+	ManagedByKubot = "kubot"
+
+	ComponentVolume       = "volume"
+	ComponentWorkspace    = "workspace"
+	ComponentSuite        = "suite"
+	ComponentMerger       = "merger"
+	ComponentDependencies = "dependencies"
+)
+
+// maxLabelValueLength is the maximum length of a label value allowed by Kubernetes
+const maxLabelValueLength = 63
+
+var invalidLabelValueChars = regexp.MustCompile(`[^-A-Za-z0-9_.]+`)
+
+// NewRunID generates a unique identifier for an execution, usable as a label value
+func NewRunID() string {
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102150405"), rand.String(5))
+}
+
+// ManagedBySelector selects every resource created by kubot
+func ManagedBySelector() string {
+	return fmt.Sprintf("%s=%s", LabelManagedBy, ManagedByKubot)
+}
+
+// suiteLabelValue converts a suite path into a valid label value, e.g. admin/users/create.robot
+// becomes admin.users.create.robot
+func suiteLabelValue(suite string) string {
+	value := invalidLabelValueChars.ReplaceAllString(strings.ReplaceAll(suite, "/", "."), "-")
+	if len(value) > maxLabelValueLength {
+		value = value[len(value)-maxLabelValueLength:]
+	}
+
+	return strings.TrimFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+}
+
+// resourceLabels returns the labels of a resource created for the given run
+func resourceLabels(runID string, component string, suite string) map[string]string {
+	labels := map[string]string{
+		LabelManagedBy: ManagedByKubot,
+		LabelComponent: component,
+		LabelRunID:     runID,
+	}
+
+	if suite != "" {
+		labels[LabelSuite] = suiteLabelValue(suite)
+	}
+
+	return labels
+}
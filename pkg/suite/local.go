@@ -0,0 +1,259 @@
+package suite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/yusufcanb/kubot/pkg/workspace"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// LocalExecutor runs every suite as a robot process on the local machine, outputs are written into LocalOutputDir
+type LocalExecutor struct {
+	workspaceDir string
+	outputRoot   string
+	env          []string // KEY=VALUE pairs added to the base environment
+	venv         string   // virtualenv robot runs in, none when empty
+}
+
+func (it *LocalExecutor) WorkspaceDir() string {
+	return it.workspaceDir
+}
+
+func (it *LocalExecutor) OutputRoot() string {
+	return it.outputRoot
+}
+
+// Execute runs robot until it exits. When the context is cancelled or at the timeout, robot is terminated to stop
+// gracefully and write its output, it is killed after the grace period.
+func (it *LocalExecutor) Execute(ctx context.Context, e Execution) error {
+	log.Debugf("local >>> %s", e.Cmd)
+
+	name, args, env := e.Cmd[0], e.Cmd[1:], it.env
+	if it.venv != "" && name == "robot" {
+		// robot on the path would not see the packages of the virtualenv
+		name, args = venvPython(it.venv), append([]string{"-m", "robot"}, args...)
+		env = append(append([]string{}, env...),
+			"VIRTUAL_ENV="+it.venv,
+			"PATH="+filepath.Dir(name)+string(os.PathListSeparator)+os.Getenv("PATH"),
+		)
+	}
+
+	process := exec.Command(name, args...)
+	process.Stdout = e.Console
+	process.Stderr = e.Console
+	process.Env = append(baseEnv(os.Environ()), env...)
+
+	if err := process.Start(); err != nil {
+		return fmt.Errorf("%w - %s", err, e.Cmd)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- process.Wait()
+	}()
+
+	var timeout, kill <-chan time.Time
+	if e.Timeout > 0 {
+		timer := time.NewTimer(e.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	// signals other than kill are not supported on windows
+	stop := func() {
+		if signalErr := process.Process.Signal(syscall.SIGTERM); signalErr != nil {
+			_ = process.Process.Kill()
+		}
+		if kill == nil {
+			kill = time.After(timeoutGracePeriod)
+		}
+	}
+
+	var err error
+	timedOut, interrupted := false, false
+	interrupt := ctx.Done()
+wait:
+	for {
+		select {
+		case err = <-done:
+			break wait
+		case <-interrupt:
+			log.Debugf("suite %s is interrupted, stopping robot", e.Suite)
+			interrupted, interrupt = true, nil
+			stop()
+		case <-timeout:
+			log.Warnf("suite %s has timed out after %s, stopping robot", e.Suite, e.Timeout)
+			timedOut, timeout = true, nil
+			stop()
+		case <-kill:
+			log.Warnf("robot has not stopped in %s, killing suite %s", timeoutGracePeriod, e.Suite)
+			kill = nil
+			_ = process.Process.Kill()
+		}
+	}
+
+	var exitErr *exec.ExitError
+	testsFailed := errors.As(err, &exitErr) && exitErr.ExitCode() >= 1 && exitErr.ExitCode() <= 250
+	stopped := errors.As(err, &exitErr) && exitErr.ExitCode() == robotStoppedExitCode
+	switch {
+	case interrupted && (err == nil || testsFailed || stopped):
+		return fmt.Errorf("%w: %v", ErrInterrupted, ctx.Err())
+	case interrupted:
+		return fmt.Errorf("%v: %w", ctx.Err(), err)
+	case err == nil:
+		return nil
+	case timedOut && (testsFailed || stopped):
+		return fmt.Errorf("%w after %s", ErrTimedOut, e.Timeout)
+	case timedOut:
+		return fmt.Errorf("%w after %s: %v", ErrTimedOutKilled, e.Timeout, err)
+	case testsFailed:
+		return ErrTestsFailed
+	default:
+		return fmt.Errorf("%w - %s", err, e.Cmd)
+	}
+}
+
+func (it *LocalExecutor) RerunArgs(output string) ([]string, error) {
+	return []string{"--rerunfailed", filepath.Join(it.outputRoot, filepath.FromSlash(output))}, nil
+}
+
+func (it *LocalExecutor) Merge(ctx context.Context, options []string, outputs []string) error {
+	rebot, err := exec.LookPath("rebot")
+	if err != nil {
+		return fmt.Errorf("rebot is not installed: %v", err)
+	}
+
+	return mergeLocally(ctx, rebot, options, outputs)
+}
+
+// Phases returns nil, local suites are running as soon as they are started
+func (it *LocalExecutor) Phases(ctx context.Context) (map[string]string, error) {
+	return nil, nil
+}
+
+// Clean has nothing to do, robot processes are killed along with the context
+func (it *LocalExecutor) Clean() {}
+
+// baseEnvNames are the host variables robot needs to run, other ones are only passed when selected like in the pods
+var baseEnvNames = map[string]bool{
+	"PATH": true, "HOME": true, "TMPDIR": true, "LANG": true,
+	// python does not start on windows without them
+	"SYSTEMROOT": true, "COMSPEC": true, "PATHEXT": true, "TEMP": true, "TMP": true, "USERPROFILE": true,
+}
+
+// baseEnv returns the variables of the environ robot needs to run
+func baseEnv(environ []string) []string {
+	env := make([]string, 0, len(baseEnvNames))
+	for _, variable := range environ {
+		name, _, _ := strings.Cut(variable, "=")
+		if baseEnvNames[strings.ToUpper(name)] {
+			env = append(env, variable)
+		}
+	}
+	return env
+}
+
+// InstallDependencies installs the dependencies of the workspace into the virtualenv under LocalOutputDir, robot
+// is run with its python afterwards. The virtualenv is kept between runs, so only the changes are installed.
+func (it *LocalExecutor) InstallDependencies(ctx context.Context, deps *Dependencies) error {
+	if deps == nil {
+		return nil
+	}
+
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		if python, err = exec.LookPath("python"); err != nil {
+			return fmt.Errorf("python is not installed: %v", err)
+		}
+	}
+
+	venv := filepath.Join(it.outputRoot, "venv")
+	if err := runLocally(ctx, it.workspaceDir, python, append([]string{"-m"}, append(venvArgs, venv)...)...); err != nil {
+		return fmt.Errorf("create virtualenv: %s", err)
+	}
+
+	args := append([]string{"-m", "pip"}, deps.installArgs()...)
+	if err := runLocally(ctx, it.workspaceDir, venvPython(venv), args...); err != nil {
+		return fmt.Errorf("install dependencies of %s: %s", deps.File(), err)
+	}
+
+	if deps.browser && exec.CommandContext(ctx, venvPython(venv), "-c", ownBrowserCheck).Run() == nil {
+		if err := runLocally(ctx, it.workspaceDir, venvPython(venv), "-m", "Browser.entry", "init"); err != nil {
+			return fmt.Errorf("initialize the Browser library: %s", err)
+		}
+	}
+
+	it.venv = venv
+
+	return nil
+}
+
+// venvPython returns the python executable of the virtualenv
+func venvPython(venv string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(venv, "Scripts", "python.exe")
+	}
+	return filepath.Join(venv, "bin", "python")
+}
+
+// runLocally runs the command in the directory, its output is printed when it fails
+func runLocally(ctx context.Context, dir string, name string, args ...string) error {
+	fmt.Fprintf(echo, "local >>> %s\n", append([]string{name}, args...))
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	combined, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v %s", err, strings.TrimSpace(string(combined)))
+	}
+
+	return nil
+}
+
+// mergeLocally runs the given rebot executable over the outputs under LocalOutputDir
+func mergeLocally(ctx context.Context, rebot string, options []string, outputs []string) error {
+	args := append(append([]string{}, options...), "--outputdir", LocalOutputDir)
+	for _, output := range outputs {
+		args = append(args, filepath.Join(LocalOutputDir, filepath.FromSlash(output)))
+	}
+
+	fmt.Fprintf(echo, "local >>> %s\n", append([]string{rebot}, args...))
+	combined, err := exec.CommandContext(ctx, rebot, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("rebot failed: %v %s", err, strings.TrimSpace(string(combined)))
+	}
+
+	return nil
+}
+
+// NewLocalExecutor creates an executor running robot in the workspace, robot gets the variables of the env and
+// only the host variables it needs to run, e.g. PATH and HOME
+func NewLocalExecutor(w *workspace.Workspace, env *Env) (*LocalExecutor, error) {
+	if _, err := exec.LookPath("robot"); err != nil {
+		return nil, fmt.Errorf("robot is not installed: %v", err)
+	}
+
+	workspaceDir, err := filepath.Abs(w.Root().Path)
+	if err != nil {
+		return nil, err
+	}
+
+	outputRoot, err := filepath.Abs(LocalOutputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalExecutor{
+		workspaceDir: workspaceDir,
+		outputRoot:   outputRoot,
+		env:          env.Vars(),
+	}, nil
+}
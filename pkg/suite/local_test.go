@@ -0,0 +1,115 @@
+package suite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalExecutor_Execute(t *testing.T) {
+	tests := []struct {
+		name        string
+		cmd         []string
+		wantErr     bool
+		wantFailed  bool
+		wantConsole string
+	}{
+		{name: "passed", cmd: []string{"sh", "-c", "echo Scripts.Google; exit 0"}, wantConsole: "Scripts.Google\n"},
+		{name: "failed tests", cmd: []string{"sh", "-c", "echo Scripts.Google >&2; exit 3"}, wantErr: true, wantFailed: true, wantConsole: "Scripts.Google\n"},
+		{name: "invalid arguments", cmd: []string{"sh", "-c", "exit 252"}, wantErr: true},
+		{name: "not installed", cmd: []string{"kubot-missing-robot"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &LocalExecutor{workspaceDir: t.TempDir(), outputRoot: t.TempDir()}
+
+			console := &bytes.Buffer{}
+			err := executor.Execute(context.Background(), Execution{Suite: "google.robot", Cmd: tt.cmd, Console: console})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrTestsFailed) != tt.wantFailed {
+				t.Errorf("Execute() error = %v, want failed tests %v", err, tt.wantFailed)
+			}
+			if got := console.String(); got != tt.wantConsole {
+				t.Errorf("Execute() console got = %q, want %q", got, tt.wantConsole)
+			}
+		})
+	}
+}
+
+func TestLocalExecutor_ExecuteEnv(t *testing.T) {
+	t.Setenv("KUBOT_TEST_HOST_SECRET", "s3cr3t")
+
+	executor := &LocalExecutor{workspaceDir: t.TempDir(), outputRoot: t.TempDir(), env: []string{"BASE_URL=https://example.com"}}
+	console := &bytes.Buffer{}
+	err := executor.Execute(context.Background(), Execution{Suite: "google.robot", Cmd: []string{"sh", "-c", "env"}, Console: console})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := console.String()
+	if strings.Contains(got, "KUBOT_TEST_HOST_SECRET") {
+		t.Errorf("robot environment got = %q, want the host variables not selected left out", got)
+	}
+	if !strings.Contains(got, "BASE_URL=https://example.com") || !strings.Contains(got, "PATH="+os.Getenv("PATH")) {
+		t.Errorf("robot environment got = %q, want the selected variables and PATH", got)
+	}
+}
+
+func TestLocalExecutor_ExecuteCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	executor := &LocalExecutor{workspaceDir: t.TempDir(), outputRoot: t.TempDir()}
+	err := executor.Execute(ctx, Execution{Suite: "google.robot", Cmd: []string{"sleep", "10"}, Console: io.Discard})
+	if err == nil || errors.Is(err, ErrTestsFailed) || errors.Is(err, ErrInterrupted) {
+		t.Errorf("Execute() error = %v, want an interruption", err)
+	}
+
+	// robot traps TERM to write its output and exits with 253
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	started := time.Now()
+	cmd := []string{"sh", "-c", "trap 'echo stopped; exit 253' TERM; while :; do sleep 0.05; done"}
+	console := &bytes.Buffer{}
+	err = executor.Execute(ctx, Execution{Suite: "google.robot", Cmd: cmd, Console: console})
+	if !errors.Is(err, ErrInterrupted) || console.String() != "stopped\n" {
+		t.Errorf("Execute() error = %v, console = %q, want robot stopped gracefully", err, console.String())
+	}
+	if elapsed := time.Since(started); elapsed >= timeoutGracePeriod {
+		t.Errorf("Execute() took %s, want robot to exit on its own", elapsed)
+	}
+}
+
+func TestLocalExecutor_ExecuteTimedOut(t *testing.T) {
+	defer func(d time.Duration) { timeoutGracePeriod = d }(timeoutGracePeriod)
+	timeoutGracePeriod = 200 * time.Millisecond
+
+	tests := []struct {
+		name string
+		trap string
+		want error
+	}{
+		{name: "stopped", trap: "exit 253", want: ErrTimedOut},
+		{name: "killed", trap: "", want: ErrTimedOutKilled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &LocalExecutor{workspaceDir: t.TempDir(), outputRoot: t.TempDir()}
+			cmd := []string{"sh", "-c", fmt.Sprintf("trap '%s' TERM; while :; do sleep 0.05; done", tt.trap)}
+
+			err := executor.Execute(context.Background(), Execution{Suite: "google.robot", Cmd: cmd, Console: io.Discard, Timeout: 100 * time.Millisecond})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Execute() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,35 +1,35 @@
 package suite
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"time"
 )
 
+// rebotTimeFormat is the timestamp format expected by rebot --starttime and --endtime
+const rebotTimeFormat = "2006-01-02 15:04:05.000"
+
 type Merger struct {
 	topLevelSuiteName string
+	rebotArgs         []string // passed to rebot after the options of kubot
 }
 
-func (it *Merger) MergeResults(v *Volume, image string, startedAt *time.Time, completedAt *time.Time) error {
-
-	suitePod, err := NewSuitePod(v, image)
-	if err != nil {
-		return err
+// MergeResults merges the given output files into a single report. Outputs are merged in order,
+// so the results of rerun tests replace the earlier ones and are annotated as re-executed.
+func (it *Merger) MergeResults(ctx context.Context, e Executor, outputs []string, startedAt *time.Time, completedAt *time.Time) error {
+	if len(outputs) == 0 {
+		return errors.New("no suite has produced an output to merge")
 	}
 
-	cmd := []string{
-		"rebot",
+	// Every suite output shares the workspace root suite, merging them
+	// rebuilds the directory hierarchy of the workspace in a single report.
+	options := []string{
+		"--merge", "--nostatusrc",
 		"--name", it.topLevelSuiteName,
-		"--starttime", startedAt.UTC().String(),
-		"--endtime", completedAt.UTC().String(),
-		"--outputdir", "/data/output", "/data/output/*/output.xml",
-	}
-
-	err = suitePod.exec(cmd)
-	defer suitePod.destroy()
-	if err != nil {
-		fmt.Errorf("merger failed: %s", err)
-		return err
+		"--starttime", startedAt.UTC().Format(rebotTimeFormat),
+		"--endtime", completedAt.UTC().Format(rebotTimeFormat),
 	}
+	options = append(options, it.rebotArgs...)
 
-	return nil
+	return e.Merge(ctx, options, outputs)
 }
@@ -4,20 +4,19 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/yusufcanb/kubot/internal/utils"
 	"github.com/yusufcanb/kubot/pkg/cluster"
+	"io"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes/scheme"
-	"k8s.io/client-go/tools/remotecommand"
-	"k8s.io/utils/env"
 	"os"
-	"os/exec"
-	"regexp"
-	"strings"
+	"sync"
 	"time"
 )
 
+const containerName = "job-container"
+
 type Pod struct {
 	cluster *cluster.Cluster
 	pod     *corev1.Pod
@@ -25,25 +24,27 @@ type Pod struct {
 	deleted bool
 }
 
-// waitUntilPodHasStarted to ensure the executor's pod in Running state
-func (it *Pod) waitUntilPodHasStarted() error {
-	// define timeout and polling interval
-	timeoutSeconds := 5 * 60 // 5 minutes
-	pollIntervalSeconds := 10
+// podStartTimeout and podPollInterval configure waiting for the pods to start
+var (
+	podStartTimeout = 5 * time.Minute
+	podPollInterval = 10 * time.Second
+)
 
+// waitUntilPodHasStarted to ensure the executor's pod in Running state
+func (it *Pod) waitUntilPodHasStarted(ctx context.Context) error {
 	// create a context with timeout and cancel functions
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSeconds)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, podStartTimeout)
 	defer cancel()
 
 	// poll the pod until it is in the `Running` state
 	for {
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("timed out waiting for pod %s/%s to start", it.pod.Namespace, it.pod.Name)
+			return fmt.Errorf("timed out waiting for pod %s/%s to start: %w", it.pod.Namespace, it.pod.Name, ctx.Err())
 		default:
-			pod, err := it.cluster.Client().CoreV1().Pods(it.pod.Namespace).Get(context.Background(), it.pod.Name, metav1.GetOptions{})
+			pod, err := it.cluster.Client().CoreV1().Pods(it.pod.Namespace).Get(ctx, it.pod.Name, metav1.GetOptions{})
 			if err != nil {
-				return fmt.Errorf("error getting pod %s/%s: %v", pod.Namespace, pod.Name, err)
+				return fmt.Errorf("error getting pod %s/%s: %v", it.pod.Namespace, it.pod.Name, err)
 			}
 			if pod.Status.Phase == corev1.PodFailed {
 				return fmt.Errorf("pod[%s] is failed", pod.Name)
@@ -51,81 +52,50 @@ func (it *Pod) waitUntilPodHasStarted() error {
 			if pod.Status.Phase == corev1.PodRunning {
 				return nil
 			}
-			time.Sleep(time.Duration(pollIntervalSeconds) * time.Second)
-		}
-	}
-}
-
-// collectEnvironmentVariablesFromOs
-func (it *Pod) collectEnvironmentVariablesFromOs() []corev1.EnvVar {
-	var envVars []corev1.EnvVar
-
-	envKeyRegex := regexp.MustCompile(`^[-._a-zA-Z][-._a-zA-Z0-9]*$`)
-
-	for _, env := range os.Environ() {
-		keyValue := strings.SplitN(env, "=", 2)
-		if len(keyValue) == 2 {
-			key := keyValue[0]
-			value := keyValue[1]
-
-			if envKeyRegex.MatchString(key) && envKeyRegex.MatchString(value) {
-				envVar := corev1.EnvVar{
-					Name:  key,
-					Value: value,
-				}
-				envVars = append(envVars, envVar)
+			select {
+			case <-ctx.Done():
+			case <-time.After(podPollInterval):
 			}
 		}
 	}
-
-	return envVars
 }
 
-// copy given file into the pod
-func (it *Pod) copy(srcPath string, destinationPath string) error {
-	fmt.Printf("%s >>> %s\n", it.pod.Name, []string{"kubectl", "cp", srcPath, fmt.Sprintf("%s:%s", it.pod.Name, destinationPath), "-n", it.pod.Namespace})
-
-	// Construct the kubectl cp command
-	cmd := exec.Command("kubectl", "cp", srcPath, fmt.Sprintf("%s:%s", it.pod.Name, destinationPath), "-n", it.pod.Namespace)
+// copy the given local directory into the destination directory of the pod
+func (it *Pod) copy(ctx context.Context, srcPath string, destinationPath string) error {
+	fmt.Fprintf(echo, "%s >>> copy %s to %s\n", it.pod.Name, srcPath, destinationPath)
 
-	// Run the command and capture the output and error streams
-	output, err := cmd.CombinedOutput()
+	archivePath, err := utils.ArchiveWorkspace(&srcPath)
 	if err != nil {
-		return fmt.Errorf("failed to copy tar file to pod: %s. Error: %v", string(output), err)
+		return err
 	}
+	defer os.Remove(archivePath)
 
-	return nil
+	return it.cluster.Executor().Upload(ctx, it.pod, containerName, archivePath, destinationPath)
 }
 
-// exec executes given command inside the pod
-func (it *Pod) exec(cmd []string) error {
-	fmt.Printf("%s >>> %s\n", it.pod.Name, cmd)
+// exec executes given command inside the pod. The output is streamed with debug logging, printed on failure otherwise.
+func (it *Pod) exec(ctx context.Context, cmd []string) error {
+	fmt.Fprintf(echo, "%s >>> %s\n", it.pod.Name, cmd)
 
 	buf := &bytes.Buffer{}
-	request := it.cluster.Client().CoreV1().RESTClient().
-		Post().
-		Namespace(it.pod.Namespace).
-		Resource("pods").
-		Name(it.pod.Name).
-		SubResource("exec").
-		VersionedParams(&corev1.PodExecOptions{
-			Command: cmd,
-			Stdin:   false,
-			Stdout:  true,
-			Stderr:  true,
-			TTY:     true,
-		}, scheme.ParameterCodec)
-
-	spdyExec, err := remotecommand.NewSPDYExecutor(it.cluster.Config(), "POST", request.URL())
-	err = spdyExec.StreamWithContext(context.Background(), remotecommand.StreamOptions{
-		Stdin:  nil,
-		Stdout: buf,
-		Stderr: buf,
-		Tty:    true,
+	var stdout io.Writer = buf
+
+	streaming := log.IsLevelEnabled(log.DebugLevel)
+	if streaming {
+		terminal := newPrefixWriter(&sync.Mutex{}, echo, it.pod.Name)
+		defer terminal.Flush()
+		stdout = terminal
+	}
+
+	err := it.cluster.Executor().Exec(ctx, it.pod, containerName, cmd, cluster.Streams{
+		Stdout: stdout,
+		TTY:    true,
 	})
 
 	if err != nil {
-		fmt.Println(buf.String())
+		if !streaming {
+			fmt.Fprintln(echo, buf.String())
+		}
 		return fmt.Errorf("%w - %s on %v/%v", err, cmd, it.pod.Namespace, it.pod.Name)
 	}
 
@@ -133,9 +103,13 @@ func (it *Pod) exec(cmd []string) error {
 }
 
 // destroy the pod instance
-func (it *Pod) destroy() error {
+func (it *Pod) destroy(ctx context.Context) error {
+	if it.deleted {
+		return nil
+	}
+
 	// Delete the Pod with the specified name in the specified namespace
-	err := it.cluster.Client().CoreV1().Pods(it.cluster.DefaultNamespace()).Delete(context.Background(), it.pod.Name, metav1.DeleteOptions{})
+	err := it.cluster.Client().CoreV1().Pods(it.cluster.DefaultNamespace()).Delete(ctx, it.pod.Name, metav1.DeleteOptions{})
 	if err != nil {
 		return err
 	}
@@ -146,67 +120,50 @@ func (it *Pod) destroy() error {
 	return nil
 }
 
-func NewSuitePod(suiteVolume *Volume, image string) (*Pod, error) {
+// newContainer creates the robot container of a suite pod, volumes are mounted by the transfer,
+// resources and environment variables are set from the pod options
+func newContainer(image string, command []string) corev1.Container {
+	return corev1.Container{
+		Name:    containerName,
+		Image:   image,
+		Command: command,
+	}
+}
+
+func NewSuitePod(ctx context.Context, t Transfer, image string, component string) (*Pod, error) {
 	suitePod := Pod{}
-	suitePod.cluster = suiteVolume.cluster
+	suitePod.cluster = t.Cluster()
 
 	// Create a new PodSpec with the job container
-	podSpec := corev1.PodSpec{
-		Containers: []corev1.Container{
-			{
-				Name:    "job-container",
-				Image:   image,
-				Command: []string{"sleep", "infinity"},
-				Env:     suitePod.collectEnvironmentVariablesFromOs(),
-				VolumeMounts: []corev1.VolumeMount{
-					{
-						Name:      suiteVolume.volume.Name,
-						MountPath: "/data",
-					},
-				},
-				Resources: corev1.ResourceRequirements{
-					Requests: corev1.ResourceList{
-						corev1.ResourceCPU:    resource.MustParse(env.GetString("KUBOT_POD_CPU_REQUEST", "250m")),     // 0.25 CPU
-						corev1.ResourceMemory: resource.MustParse(env.GetString("KUBOT_POD_MEMORY_REQUEST", "128Mi")), // 128 MB RAM
-					},
-					Limits: corev1.ResourceList{
-						corev1.ResourceCPU:    resource.MustParse(env.GetString("KUBOT_POD_CPU_LIMIT", "250m")),     // 0.25 CPU
-						corev1.ResourceMemory: resource.MustParse(env.GetString("KUBOT_POD_MEMORY_LIMIT", "256Mi")), // 256 MB RAM
-					},
-				},
-			},
-		},
-		Volumes: []corev1.Volume{
-			{
-				Name: suiteVolume.volume.Name,
-				VolumeSource: corev1.VolumeSource{
-					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: suiteVolume.volume.Name,
-					},
-				},
-			},
-		},
-		RestartPolicy: corev1.RestartPolicyNever,
+	podSpec, err := newPodSpec(t, image, []string{"sleep", "infinity"}, component, DefaultResourceProfile)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create a new Pod object with the PodSpec
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: "kubot-",
-			Namespace:    suitePod.cluster.DefaultNamespace(),
+			GenerateName:    "kubot-",
+			Namespace:       suitePod.cluster.DefaultNamespace(),
+			Labels:          resourceLabels(t.RunID(), component, ""),
+			OwnerReferences: t.ownerReferences(),
 		},
 		Spec: podSpec,
 	}
 
 	// Create the Pod in the cluster
-	podInterface, err := suitePod.cluster.Client().CoreV1().Pods(suitePod.cluster.DefaultNamespace()).Create(context.Background(), pod, metav1.CreateOptions{})
+	podInterface, err := suitePod.cluster.Client().CoreV1().Pods(suitePod.cluster.DefaultNamespace()).Create(ctx, pod, metav1.CreateOptions{})
 	if err != nil {
 		return nil, err
 	}
 
 	suitePod.pod = podInterface
-	err = suitePod.waitUntilPodHasStarted()
+	err = suitePod.waitUntilPodHasStarted(ctx)
 	if err != nil {
+		// the pod would be left behind otherwise, the context may already be cancelled
+		cleanupCtx, cancel := CleanupContext()
+		defer cancel()
+		_ = suitePod.destroy(cleanupCtx)
 		return nil, err
 	}
 
@@ -0,0 +1,65 @@
+package suite
+
+import (
+	"context"
+	"github.com/yusufcanb/kubot/pkg/cluster/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	"testing"
+	"time"
+)
+
+func TestNewSuitePod(t *testing.T) {
+	defer func(timeout, interval time.Duration) { podStartTimeout, podPollInterval = timeout, interval }(podStartTimeout, podPollInterval)
+	podStartTimeout, podPollInterval = 100*time.Millisecond, 10*time.Millisecond
+
+	tests := []struct {
+		name    string
+		phase   corev1.PodPhase
+		wantErr bool
+	}{
+		{name: "running", phase: corev1.PodRunning},
+		{name: "failed", phase: corev1.PodFailed, wantErr: true},
+		{name: "never started", phase: corev1.PodPending, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, client, _ := fake.NewCluster("kubot")
+			client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				action.(k8stesting.CreateAction).GetObject().(*corev1.Pod).Status.Phase = tt.phase
+				return false, nil, nil
+			})
+
+			ctx := context.Background()
+			v, err := NewVolume(ctx, c, "run-1", VolumeOptions{Size: "1Gi"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			suitePod, err := NewSuitePod(ctx, v, "robot", ComponentMerger)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSuitePod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			pods, _ := client.CoreV1().Pods("kubot").List(ctx, metav1.ListOptions{})
+			if tt.wantErr {
+				if len(pods.Items) != 0 {
+					t.Errorf("pods got = %d, want the pod deleted", len(pods.Items))
+				}
+				return
+			}
+
+			if len(pods.Items) != 1 || pods.Items[0].Labels[LabelComponent] != ComponentMerger {
+				t.Fatalf("pods got = %+v", pods.Items)
+			}
+			if err := suitePod.destroy(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if err := suitePod.destroy(ctx); err != nil {
+				t.Errorf("destroy() is not idempotent: %v", err)
+			}
+		})
+	}
+}
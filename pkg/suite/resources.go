@@ -0,0 +1,146 @@
+package suite
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sort"
+	"strings"
+)
+
+// DefaultResourceProfile is used by the suites without a profile and by the workspace and merger pods
+const DefaultResourceProfile = "default"
+
+// ResourceProfile is a named set of requests and limits of the robot container, e.g.
+// {"requests": {"cpu": "1", "memory": "2Gi"}, "limits": {"memory": "4Gi", "nvidia.com/gpu": "1"}}
+type ResourceProfile struct {
+	Requests map[string]string `mapstructure:"requests"`
+	Limits   map[string]string `mapstructure:"limits"`
+}
+
+// defaultResources are the resources of the default profile unless the config defines it
+var defaultResources = ResourceProfile{
+	Requests: map[string]string{"cpu": "250m", "memory": "128Mi"},
+	Limits:   map[string]string{"cpu": "250m", "memory": "256Mi"},
+}
+
+// ResourceProfiles are the parsed resource profiles of a run
+type ResourceProfiles struct {
+	profiles map[string]corev1.ResourceRequirements
+}
+
+// Has tells whether the profile is defined, profile names are case insensitive
+func (it *ResourceProfiles) Has(name string) bool {
+	_, ok := it.profiles[strings.ToLower(name)]
+	return ok
+}
+
+// requirements returns the resources of the profile, the default profile when the name is empty
+// or the profiles are nil
+func (it *ResourceProfiles) requirements(name string) (corev1.ResourceRequirements, error) {
+	if name == "" {
+		name = DefaultResourceProfile
+	}
+	if it == nil {
+		it = &ResourceProfiles{}
+	}
+
+	requirements, ok := it.profiles[strings.ToLower(name)]
+	if !ok && name == DefaultResourceProfile {
+		return defaultResources.parse()
+	}
+	if !ok {
+		return corev1.ResourceRequirements{}, fmt.Errorf("unknown resource profile %q", name)
+	}
+
+	return requirements, nil
+}
+
+// parse validates the resource names and quantities of the profile
+func (it ResourceProfile) parse() (corev1.ResourceRequirements, error) {
+	requests, err := parseResourceList(it.Requests, "request")
+	if err != nil {
+		return corev1.ResourceRequirements{}, err
+	}
+	limits, err := parseResourceList(it.Limits, "limit")
+	if err != nil {
+		return corev1.ResourceRequirements{}, err
+	}
+
+	for name, request := range requests {
+		limit, ok := limits[name]
+		switch {
+		case isExtendedResource(name) && (!ok || request.Cmp(limit) != 0):
+			return corev1.ResourceRequirements{}, fmt.Errorf("extended resource %s cannot be overcommitted, its request must equal its limit", name)
+		case ok && request.Cmp(limit) > 0:
+			return corev1.ResourceRequirements{}, fmt.Errorf("%s request %s is greater than its limit %s", name, request.String(), limit.String())
+		}
+	}
+
+	return corev1.ResourceRequirements{Requests: requests, Limits: limits}, nil
+}
+
+func parseResourceList(quantities map[string]string, kind string) (corev1.ResourceList, error) {
+	if len(quantities) == 0 {
+		return nil, nil
+	}
+
+	list := make(corev1.ResourceList, len(quantities))
+	for name, value := range quantities {
+		resourceName := corev1.ResourceName(strings.ToLower(name))
+		if !isStandardResource(resourceName) && !isExtendedResource(resourceName) {
+			return nil, fmt.Errorf("unknown resource %q, use cpu, memory, ephemeral-storage or an extended resource with a domain, e.g. nvidia.com/gpu", name)
+		}
+
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s %q, e.g. 500m, 2 or 1Gi: %v", name, kind, value, err)
+		}
+		if quantity.Sign() < 0 {
+			return nil, fmt.Errorf("invalid %s %s %q, it cannot be negative", name, kind, value)
+		}
+
+		list[resourceName] = quantity
+	}
+
+	return list, nil
+}
+
+func isStandardResource(name corev1.ResourceName) bool {
+	switch name {
+	case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+		return true
+	}
+	return strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix)
+}
+
+// isExtendedResource tells whether the name is a device plugin resource, e.g. nvidia.com/gpu
+func isExtendedResource(name corev1.ResourceName) bool {
+	domain, _, found := strings.Cut(string(name), "/")
+	return found && !strings.HasSuffix(domain, "kubernetes.io") && len(validation.IsQualifiedName(string(name))) == 0
+}
+
+// NewResourceProfiles parses the profiles, every quantity is validated so pods are never created with invalid resources
+func NewResourceProfiles(profiles map[string]ResourceProfile) (*ResourceProfiles, error) {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parsed := &ResourceProfiles{profiles: make(map[string]corev1.ResourceRequirements, len(profiles)+1)}
+	for _, name := range names {
+		requirements, err := profiles[name].parse()
+		if err != nil {
+			return nil, fmt.Errorf("resource profile %q: %s", name, err)
+		}
+		parsed.profiles[strings.ToLower(name)] = requirements
+	}
+
+	if !parsed.Has(DefaultResourceProfile) {
+		parsed.profiles[DefaultResourceProfile], _ = defaultResources.parse()
+	}
+
+	return parsed, nil
+}
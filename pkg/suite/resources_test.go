@@ -0,0 +1,81 @@
+package suite
+
+import (
+	"context"
+	"github.com/yusufcanb/kubot/pkg/cluster/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestNewResourceProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile ResourceProfile
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			profile: ResourceProfile{
+				Requests: map[string]string{"cpu": "1", "memory": "2Gi", "ephemeral-storage": "1Gi", "nvidia.com/gpu": "1"},
+				Limits:   map[string]string{"memory": "4Gi", "nvidia.com/gpu": "1"},
+			},
+		},
+		{name: "invalid quantity", profile: ResourceProfile{Limits: map[string]string{"memory": "4GB"}}, wantErr: true},
+		{name: "negative quantity", profile: ResourceProfile{Requests: map[string]string{"cpu": "-1"}}, wantErr: true},
+		{name: "unknown resource", profile: ResourceProfile{Limits: map[string]string{"gpu": "1"}}, wantErr: true},
+		{name: "request above limit", profile: ResourceProfile{Requests: map[string]string{"cpu": "2"}, Limits: map[string]string{"cpu": "500m"}}, wantErr: true},
+		{name: "overcommitted extended resource", profile: ResourceProfile{Requests: map[string]string{"nvidia.com/gpu": "1"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profiles, err := NewResourceProfiles(map[string]ResourceProfile{"Browser-Heavy": tt.profile})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewResourceProfiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (!profiles.Has("browser-heavy") || !profiles.Has(DefaultResourceProfile)) {
+				t.Errorf("NewResourceProfiles() got = %v, want the profile and the default profile", profiles.profiles)
+			}
+		})
+	}
+}
+
+func TestNewSuiteJob_ResourceProfile(t *testing.T) {
+	profiles, err := NewResourceProfiles(map[string]ResourceProfile{
+		"browser": {Limits: map[string]string{"cpu": "2", "memory": "4Gi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, client, _ := fake.NewCluster("kubot")
+	ctx := context.Background()
+	v, err := NewVolume(ctx, c, "run-1", VolumeOptions{Size: "1Gi", Pods: PodOptions{Resources: profiles}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		profile    string
+		wantMemory string
+	}{
+		{profile: "browser", wantMemory: "4Gi"},
+		{profile: "", wantMemory: "256Mi"},
+	}
+	for _, tt := range tests {
+		suiteJob, err := NewSuiteJob(ctx, v, "robot", "google.robot", []string{"robot"}, JobOptions{ResourceProfile: tt.profile})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job, _ := client.BatchV1().Jobs("kubot").Get(ctx, suiteJob.job.Name, metav1.GetOptions{})
+		limits := job.Spec.Template.Spec.Containers[0].Resources.Limits
+		if got := limits[corev1.ResourceMemory]; got.String() != tt.wantMemory {
+			t.Errorf("memory limit of profile %q got = %v, want %v", tt.profile, got.String(), tt.wantMemory)
+		}
+	}
+
+	if _, err := NewSuiteJob(ctx, v, "robot", "google.robot", []string{"robot"}, JobOptions{ResourceProfile: "missing"}); err == nil {
+		t.Error("NewSuiteJob() error = nil, want an unknown profile")
+	}
+}
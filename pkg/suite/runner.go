@@ -1,87 +1,406 @@
 package suite
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/yusufcanb/kubot/pkg/batch"
-	"github.com/yusufcanb/kubot/pkg/cluster"
-	"github.com/yusufcanb/kubot/pkg/workspace"
+	"github.com/yusufcanb/kubot/pkg/progress"
+	"github.com/yusufcanb/kubot/pkg/result"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 )
 
+// suiteResult keeps track of the executions of a suite
+type suiteResult struct {
+	outputs []string // output files in the order they were produced
+	err     error    // error of the last execution
+}
+
 type Runner struct {
-	cluster *cluster.Cluster
+	executor Executor
+
+	merger      *Merger
+	rerunFailed int
+	robotArgs   []string // passed to robot after the options of kubot
+
+	console   io.Writer  // terminal the suite consoles are streamed into, nil when quiet
+	consoleMu sync.Mutex // keeps the console lines of concurrent suites apart
 
-	merger *Merger
-	image  string
+	progress *progress.View // nil when disabled
+
+	mu      sync.Mutex
+	results map[string]*suiteResult
 
 	startedAt   time.Time
 	completedAt time.Time
+	output      *result.Output
+}
+
+// outputName returns the name of the output file of the given execution attempt of a suite
+func outputName(attempt int) string {
+	if attempt == 0 {
+		return "output.xml"
+	}
+	return fmt.Sprintf("rerun-%d.xml", attempt)
 }
 
-func (it *Runner) executeSuite(v *Volume, suiteName string) error {
-	suitePod, err := NewSuitePod(v, it.image)
+func (it *Runner) executeSuite(ctx context.Context, shard batch.Shard, attempt int) error {
+	suiteName := shard.Name
+	outputDir := path.Join(it.executor.OutputRoot(), suiteName)
+	workspaceDir := it.executor.WorkspaceDir()
+	longName := suiteLongName(workspaceDir, shard.Suite)
+
+	// robot is executed against the workspace root and the suite is picked by its long name,
+	// so every output.xml shares the same root suite and keeps the directory hierarchy.
+	// Test groups of a suite are picked by their long names as well and merged back into the suite.
+	cmd := []string{
+		"robot", "--log", "NONE", "--report", "NONE",
+		"--outputdir", outputDir,
+		"--output", outputName(attempt),
+	}
+	if attempt > 0 {
+		rerunArgs, err := it.executor.RerunArgs(path.Join(suiteName, outputName(attempt-1)))
+		if err != nil {
+			it.record(suiteName, "", err)
+			return err
+		}
+		cmd = append(cmd, rerunArgs...)
+	} else {
+		for _, test := range shard.Tests {
+			cmd = append(cmd, "--test", escapePattern(longName+"."+test))
+		}
+	}
+	if selectsTests(it.robotArgs) {
+		// --include or --exclude may leave a suite without tests, robot would fail it otherwise
+		cmd = append(cmd, "--runemptysuite")
+	}
+	cmd = append(cmd, it.robotArgs...)
+	cmd = append(cmd, "--suite", longName, workspaceDir)
+
+	console, closeConsole, err := it.openConsole(suiteName, attempt)
 	if err != nil {
+		it.record(suiteName, "", err)
 		return err
 	}
 
-	err = suitePod.exec([]string{
-		"robot", "--log", "NONE", "--report", "NONE", "--outputdir", fmt.Sprintf("/data/output/%s", suiteName), fmt.Sprintf("/data/workspace/scripts/%s", suiteName),
+	err = it.executor.Execute(ctx, Execution{
+		Suite:   suiteName,
+		Cmd:     cmd,
+		Console: console,
+		Timeout: shard.Timeout,
+		Profile: shard.Profile,
 	})
-	defer suitePod.destroy()
-	if err != nil {
+	closeConsole()
+	switch {
+	case ctx.Err() != nil && errors.Is(err, ErrInterrupted):
+		err = fmt.Errorf("suite %s stopped: %w", suiteName, err)
+	case ctx.Err() != nil:
+		err = fmt.Errorf("suite %s interrupted: %w", suiteName, ctx.Err())
+	case errors.Is(err, ErrTimedOut) || errors.Is(err, ErrTimedOutKilled):
+		log.Errorf("suite %s: %s", suiteName, err)
+	case err != nil && !errors.Is(err, ErrTestsFailed):
 		log.Errorf("robot script failed: %s", err)
-		return err
 	}
 
-	return nil
+	it.record(suiteName, path.Join(suiteName, outputName(attempt)), err)
+
+	return err
+}
+
+// openConsole creates the console log of a suite execution next to its output, the console is streamed
+// into the terminal as well unless the runner is quiet
+func (it *Runner) openConsole(suiteName string, attempt int) (io.Writer, func(), error) {
+	dir := filepath.Join(LocalOutputDir, filepath.FromSlash(suiteName))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create console log of suite %s: %v", suiteName, err)
+	}
+
+	file, err := os.Create(filepath.Join(dir, consoleName(attempt)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create console log of suite %s: %v", suiteName, err)
+	}
+
+	if it.console == nil {
+		return file, func() { _ = file.Close() }, nil
+	}
+
+	terminal := newPrefixWriter(&it.consoleMu, it.console, suiteName)
+	return io.MultiWriter(file, terminal), func() {
+		terminal.Flush()
+		_ = file.Close()
+	}, nil
+}
+
+// record saves the outcome of a suite execution, the output file only exists if robot has run the suite
+// or has been stopped gracefully at the timeout or an interruption. Output paths are relative to the output root.
+func (it *Runner) record(suiteName string, output string, err error) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	execution, ok := it.results[suiteName]
+	if !ok {
+		execution = &suiteResult{}
+		it.results[suiteName] = execution
+	}
+
+	if err == nil || errors.Is(err, ErrTestsFailed) || errors.Is(err, ErrTimedOut) || errors.Is(err, ErrInterrupted) {
+		execution.outputs = append(execution.outputs, output)
+	}
+	execution.err = err
+}
+
+// failedSuites returns the suites whose last execution has failed tests, timed out suites are not rerun
+func (it *Runner) failedSuites(suites []string) []string {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	failed := make([]string, 0)
+	for _, suiteName := range suites {
+		if execution, ok := it.results[suiteName]; ok && errors.Is(execution.err, ErrTestsFailed) {
+			failed = append(failed, suiteName)
+		}
+	}
+
+	return failed
+}
+
+// notExecutedSuites returns the suites whose last execution has failed without running the tests
+func (it *Runner) notExecutedSuites(suites []string) []string {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	notExecuted := make([]string, 0)
+	for _, suiteName := range suites {
+		execution, ok := it.results[suiteName]
+		if !ok || (execution.err != nil && !errors.Is(execution.err, ErrTestsFailed) && !errors.Is(execution.err, ErrTimedOut)) {
+			notExecuted = append(notExecuted, suiteName)
+		}
+	}
+
+	return notExecuted
+}
+
+// timedOutSuites returns the suites whose last execution has been stopped at the timeout
+func (it *Runner) timedOutSuites(suites []string) []string {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	timedOut := make([]string, 0)
+	for _, suiteName := range suites {
+		execution, ok := it.results[suiteName]
+		if ok && (errors.Is(execution.err, ErrTimedOut) || errors.Is(execution.err, ErrTimedOutKilled)) {
+			timedOut = append(timedOut, suiteName)
+		}
+	}
+
+	return timedOut
+}
+
+// outputs returns the output files of every execution, first runs come before reruns
+// so rebot --merge replaces the failed tests with their rerun results.
+func (it *Runner) outputs(suites []string) []string {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	outputs := make([]string, 0)
+	for attempt := 0; attempt <= it.rerunFailed; attempt++ {
+		for _, suiteName := range suites {
+			if execution, ok := it.results[suiteName]; ok && len(execution.outputs) > attempt {
+				outputs = append(outputs, execution.outputs[attempt])
+			}
+		}
+	}
+
+	return outputs
+}
+
+var suiteNamePrefix = regexp.MustCompile(`^\d+__`)
+
+// suiteLongName converts a suite path into the dotted long name Robot Framework gives it
+// when the workspace root is executed. Robot matches --suite names ignoring case, spaces
+// and underscores so only extensions and ordering prefixes need to be removed.
+func suiteLongName(workspaceDir string, suite string) string {
+	parts := append([]string{filepath.Base(filepath.FromSlash(workspaceDir))}, strings.Split(suite, "/")...)
+	for i, part := range parts {
+		part = strings.TrimSuffix(part, path.Ext(part))
+		parts[i] = suiteNamePrefix.ReplaceAllString(part, "")
+	}
+
+	return strings.Join(parts, ".")
 }
 
-func (it *Runner) Run(w *workspace.Workspace, v *Volume, batchSize int) error {
+// escapePattern escapes the glob characters robot would interpret in a --test name
+func escapePattern(name string) string {
+	return strings.NewReplacer("[", "[[]", "*", "[*]", "?", "[?]").Replace(name)
+}
 
-	scriptBatch := batch.NewBatch(batchSize, w)
+// Run executes the shards and merges their results. When the context is cancelled, running suites are stopped
+// and the results of the completed ones are still merged and downloaded.
+func (it *Runner) Run(ctx context.Context, shards []batch.Shard, concurrency int) error {
+	suites := make([]string, 0, len(shards))
+	shardsByName := make(map[string]batch.Shard, len(shards))
+	for _, shard := range shards {
+		suites = append(suites, shard.Name)
+		shardsByName[shard.Name] = shard
+	}
 
+	scheduler := batch.NewScheduler(concurrency)
+	scheduler.OnEvent(it.logEvent)
+
+	it.results = make(map[string]*suiteResult)
 	it.startedAt = time.Now()
 
-	for {
-		items := scriptBatch.Next()
-		if items == nil {
-			break
+	stopProgress := func() {}
+	if it.progress != nil {
+		scheduler.OnEvent(it.progress.OnEvent)
+
+		viewCtx, stopView := context.WithCancel(ctx)
+		viewDone := make(chan struct{})
+		go func() {
+			defer close(viewDone)
+			it.progress.Run(viewCtx, it.executor.Phases)
+		}()
+		stopProgress = func() {
+			stopView()
+			<-viewDone
 		}
-		var wg sync.WaitGroup
-
-		for _, file := range items {
-			wg.Add(1)
-			go func(filename string) {
-				_ = it.executeSuite(v, filename)
-				defer wg.Done()
-			}(file)
-		} // execute every script in the batch
-		wg.Wait()
-	} // process batches
+	}
 
+	pending := suites
+	for attempt := 0; attempt <= it.rerunFailed && len(pending) > 0 && ctx.Err() == nil; attempt++ {
+		if attempt > 0 {
+			log.Infof("rerunning %d failed suites, attempt %d of %d", len(pending), attempt, it.rerunFailed)
+		}
+		if it.progress != nil {
+			it.progress.Queue(pending)
+		}
+
+		scheduler.Run(ctx, pending, func(suiteName string) error {
+			return it.executeSuite(ctx, shardsByName[suiteName], attempt)
+		})
+
+		pending = it.failedSuites(pending)
+	}
+
+	stopProgress()
 	it.completedAt = time.Now()
-	time.Sleep(5 * time.Second) // Wait for all the buffers to be completed.
 
-	err := it.merger.MergeResults(v, it.image, &it.startedAt, &it.completedAt)
-	defer v.DownloadOutput()
+	collectCtx := ctx
+	if ctx.Err() != nil {
+		log.Warn("execution interrupted, stopping the running suites and collecting partial results")
+		it.Clean()
+
+		var cancel context.CancelFunc
+		collectCtx, cancel = CleanupContext()
+		defer cancel()
+	}
+
+	err := it.merger.MergeResults(collectCtx, it.executor, it.outputs(suites), &it.startedAt, &it.completedAt)
 	if err != nil {
 		log.Errorf("merging failed: %s", err)
 		return err
 	}
 
+	it.output, err = result.ParseFile(filepath.Join(LocalOutputDir, "output.xml"))
+	if err != nil {
+		return err
+	}
+
+	err = result.WriteSummary(os.Stdout, it.output)
 	if err != nil {
 		return err
 	}
 
+	if timedOut := it.timedOutSuites(suites); len(timedOut) > 0 {
+		fmt.Printf("\nTimed out suites (%d):\n", len(timedOut))
+		for _, suiteName := range timedOut {
+			fmt.Printf("  %s (%s)\n", suiteName, shardsByName[suiteName].Timeout)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if notExecuted := it.notExecutedSuites(suites); len(notExecuted) > 0 {
+		return fmt.Errorf("%d suites could not be executed: %s", len(notExecuted), strings.Join(notExecuted, ", "))
+	}
+
 	return nil
 }
 
-func NewRunner(c *cluster.Cluster, image string, topLevelSuiteName string) *Runner {
+// Clean stops the running suites
+func (it *Runner) Clean() {
+	it.executor.Clean()
+}
+
+// SetArgs sets the arguments passed through to robot and rebot, they must not conflict with the options of kubot
+func (it *Runner) SetArgs(robotArgs []string, rebotArgs []string) {
+	it.robotArgs = robotArgs
+	it.merger.rebotArgs = rebotArgs
+}
+
+// SetProgress sets the view rendering the progress of the suites while they are running
+func (it *Runner) SetProgress(view *progress.View) {
+	it.progress = view
+}
+
+// Durations returns the durations of the first executions of the suites, taken from their outputs.
+// Reruns are not included, they only run the failed tests.
+func (it *Runner) Durations() map[string]time.Duration {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	durations := make(map[string]time.Duration)
+	for suiteName, execution := range it.results {
+		if len(execution.outputs) == 0 {
+			continue
+		}
+
+		output, err := result.ParseFile(filepath.Join(LocalOutputDir, filepath.FromSlash(execution.outputs[0])))
+		if err != nil {
+			log.Debugf("duration of suite %s is unknown: %s", suiteName, err)
+			continue
+		}
+		if elapsed := output.Suite.Status.Elapsed(); elapsed > 0 {
+			durations[suiteName] = elapsed
+		}
+	}
+
+	return durations
+}
+
+// Output returns the merged results of the last run
+func (it *Runner) Output() *result.Output {
+	return it.output
+}
+
+// logEvent logs the suite events emitted by the scheduler
+func (it *Runner) logEvent(e batch.Event) {
+	switch {
+	case e.Type == batch.SuiteStarted:
+		log.Infof("suite %s started", e.Suite)
+	case e.Err != nil:
+		log.Warnf("suite %s finished with error: %s", e.Suite, e.Err)
+	default:
+		log.Infof("suite %s finished", e.Suite)
+	}
+}
+
+// NewRunner creates a runner streaming the suite consoles into the given writer, consoles are only saved when it is nil
+func NewRunner(executor Executor, topLevelSuiteName string, rerunFailed int, console io.Writer) *Runner {
 	return &Runner{
-		cluster: c,
-		image:   image,
+		executor:    executor,
+		rerunFailed: rerunFailed,
+		console:     console,
 		merger: &Merger{
 			topLevelSuiteName: topLevelSuiteName,
 		},
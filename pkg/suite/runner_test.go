@@ -0,0 +1,225 @@
+package suite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yusufcanb/kubot/pkg/batch"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSuiteLongName(t *testing.T) {
+	tests := []struct {
+		workspaceDir string
+		suite        string
+		want         string
+	}{
+		{workspaceDir: "/data/workspace/scripts", suite: "google.robot", want: "scripts.google"},
+		{workspaceDir: "/data/workspace/scripts", suite: "admin/users/create_user.robot", want: "scripts.admin.users.create_user"},
+		{workspaceDir: "/data/workspace/01__tests", suite: "02__checkout/01__cart.robot", want: "tests.checkout.cart"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.suite, func(t *testing.T) {
+			if got := suiteLongName(tt.workspaceDir, tt.suite); got != tt.want {
+				t.Errorf("suiteLongName() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Scripts.Google.Visit Page", want: "Scripts.Google.Visit Page"},
+		{name: "Scripts.Google.Is * Valid?", want: "Scripts.Google.Is [*] Valid[?]"},
+		{name: "Scripts.Google.List [1]", want: "Scripts.Google.List [[]1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapePattern(tt.name); got != tt.want {
+				t.Errorf("escapePattern() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunner_RerunOutputs(t *testing.T) {
+	r := NewRunner(nil, "", 2, nil)
+	r.results = make(map[string]*suiteResult)
+
+	r.record("a.robot", "a.robot/output.xml", ErrTestsFailed)
+	r.record("b.robot", "b.robot/output.xml", nil)
+	r.record("c.robot", "c.robot/output.xml", errors.New("job failed: BackoffLimitExceeded"))
+	r.record("d.robot", "d.robot/output.xml", ErrTestsFailed)
+
+	suites := []string{"a.robot", "b.robot", "c.robot", "d.robot"}
+	if got, want := r.failedSuites(suites), []string{"a.robot", "d.robot"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("failedSuites() got = %v, want %v", got, want)
+	}
+
+	r.record("a.robot", "a.robot/rerun-1.xml", nil)
+	r.record("d.robot", "d.robot/rerun-1.xml", ErrTestsFailed)
+	if got, want := r.failedSuites(suites), []string{"d.robot"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("failedSuites() got = %v, want %v", got, want)
+	}
+
+	r.record("d.robot", "d.robot/rerun-2.xml", nil)
+
+	want := []string{
+		"a.robot/output.xml",
+		"b.robot/output.xml",
+		"d.robot/output.xml",
+		"a.robot/rerun-1.xml",
+		"d.robot/rerun-1.xml",
+		"d.robot/rerun-2.xml",
+	}
+	if got := r.outputs(suites); !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs() got = %v, want %v", got, want)
+	}
+}
+
+func TestRunner_RecordTimedOut(t *testing.T) {
+	r := NewRunner(nil, "", 1, nil)
+	r.results = make(map[string]*suiteResult)
+	suites := []string{"a.robot", "b.robot"}
+
+	r.record("a.robot", "a.robot/output.xml", fmt.Errorf("%w after 1m0s", ErrTimedOut))
+	r.record("b.robot", "b.robot/output.xml", fmt.Errorf("%w after 1m0s: signal: killed", ErrTimedOutKilled))
+
+	if got, want := r.outputs(suites), []string{"a.robot/output.xml"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs() got = %v, want the partial output of the stopped suite %v", got, want)
+	}
+	if got := r.failedSuites(suites); len(got) != 0 {
+		t.Errorf("failedSuites() got = %v, want timed out suites not to be rerun", got)
+	}
+	if got, want := r.notExecutedSuites(suites), []string{"b.robot"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("notExecutedSuites() got = %v, want %v", got, want)
+	}
+	if got := r.timedOutSuites(suites); !reflect.DeepEqual(got, suites) {
+		t.Errorf("timedOutSuites() got = %v, want %v", got, suites)
+	}
+}
+
+func TestRunner_RecordInterrupted(t *testing.T) {
+	r := NewRunner(nil, "", 0, nil)
+	r.results = make(map[string]*suiteResult)
+	suites := []string{"a.robot", "b.robot"}
+
+	r.record("a.robot", "a.robot/output.xml", fmt.Errorf("suite a.robot stopped: %w: context canceled", ErrInterrupted))
+	r.record("b.robot", "b.robot/output.xml", fmt.Errorf("suite b.robot interrupted: %w", context.Canceled))
+
+	if got, want := r.outputs(suites), []string{"a.robot/output.xml"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs() got = %v, want the partial output of the stopped suite %v", got, want)
+	}
+}
+
+// recordingExecutor records the executed commands, every execution has failed tests
+type recordingExecutor struct {
+	commands map[string][]string
+}
+
+func (it *recordingExecutor) WorkspaceDir() string { return "/data/workspace/scripts" }
+func (it *recordingExecutor) OutputRoot() string   { return "/data/output" }
+func (it *recordingExecutor) Clean()               {}
+
+func (it *recordingExecutor) Phases(ctx context.Context) (map[string]string, error) {
+	return nil, nil
+}
+
+func (it *recordingExecutor) Execute(ctx context.Context, e Execution) error {
+	it.commands[e.Suite] = e.Cmd
+	return ErrTestsFailed
+}
+
+func (it *recordingExecutor) RerunArgs(output string) ([]string, error) {
+	return []string{"--rerunfailed", output}, nil
+}
+
+func (it *recordingExecutor) Merge(ctx context.Context, options []string, outputs []string) error {
+	return nil
+}
+
+func TestRunner_ExecuteTestGroup(t *testing.T) {
+	chdir(t, t.TempDir())
+	executor := &recordingExecutor{commands: make(map[string][]string)}
+	r := NewRunner(executor, "", 1, nil)
+	r.results = make(map[string]*suiteResult)
+
+	shard := batch.Shard{Name: "admin/users.robot/group-2", Suite: "admin/users.robot", Tests: []string{"Update", "Delete *"}}
+
+	_ = r.executeSuite(context.Background(), shard, 0)
+	want := "robot --log NONE --report NONE --outputdir /data/output/admin/users.robot/group-2 --output output.xml " +
+		"--test scripts.admin.users.Update --test scripts.admin.users.Delete [*] --suite scripts.admin.users /data/workspace/scripts"
+	if got := strings.Join(executor.commands[shard.Name], " "); got != want {
+		t.Errorf("executeSuite() command got = %v, want %v", got, want)
+	}
+
+	_ = r.executeSuite(context.Background(), shard, 1)
+	want = "robot --log NONE --report NONE --outputdir /data/output/admin/users.robot/group-2 --output rerun-1.xml " +
+		"--rerunfailed admin/users.robot/group-2/output.xml --suite scripts.admin.users /data/workspace/scripts"
+	if got := strings.Join(executor.commands[shard.Name], " "); got != want {
+		t.Errorf("executeSuite() rerun command got = %v, want %v", got, want)
+	}
+
+	if got, want := r.outputs([]string{shard.Name}), []string{"admin/users.robot/group-2/output.xml", "admin/users.robot/group-2/rerun-1.xml"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs() got = %v, want %v", got, want)
+	}
+}
+
+func TestRunner_ExecuteRobotArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		robotArgs []string
+		want      string
+	}{
+		{name: "none", want: "--output output.xml --suite"},
+		{name: "variables", robotArgs: []string{"--variable", "ENV:staging"}, want: "--output output.xml --variable ENV:staging --suite"},
+		{name: "include", robotArgs: []string{"--include", "smoke"}, want: "--output output.xml --runemptysuite --include smoke --suite"},
+		{name: "exclude prefix", robotArgs: []string{"--exc", "wip"}, want: "--output output.xml --runemptysuite --exc wip --suite"},
+		{name: "short include", robotArgs: []string{"-i", "smoke"}, want: "--output output.xml --runemptysuite -i smoke --suite"},
+		{name: "variable value", robotArgs: []string{"--variable", "--include"}, want: "--output output.xml --variable --include --suite"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chdir(t, t.TempDir())
+			executor := &recordingExecutor{commands: make(map[string][]string)}
+			r := NewRunner(executor, "", 0, nil)
+			r.results = make(map[string]*suiteResult)
+			r.SetArgs(tt.robotArgs, nil)
+
+			shard := batch.Shard{Name: "google.robot", Suite: "google.robot"}
+			_ = r.executeSuite(context.Background(), shard, 0)
+			if got := strings.Join(executor.commands[shard.Name], " "); !strings.Contains(got, tt.want) {
+				t.Errorf("executeSuite() command got = %v, want %v in it", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuiteLabelValue(t *testing.T) {
+	tests := []struct {
+		suite string
+		want  string
+	}{
+		{suite: "google.robot", want: "google.robot"},
+		{suite: "admin/users/create user.robot", want: "admin.users.create-user.robot"},
+		{suite: "_private/ünïcode.robot", want: "private.-n-code.robot"},
+		{suite: "very/long/path/to/a/suite/with/a/name/longer/than/the/label/limit/allows.robot", want: "to.a.suite.with.a.name.longer.than.the.label.limit.allows.robot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.suite, func(t *testing.T) {
+			got := suiteLabelValue(tt.suite)
+			if got != tt.want {
+				t.Errorf("suiteLabelValue() got = %v, want %v", got, tt.want)
+			}
+			if errs := validation.IsValidLabelValue(got); len(errs) > 0 {
+				t.Errorf("suiteLabelValue() got invalid label value %v: %v", got, errs)
+			}
+		})
+	}
+}
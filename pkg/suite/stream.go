@@ -0,0 +1,354 @@
+package suite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/yusufcanb/kubot/internal/utils"
+	"github.com/yusufcanb/kubot/pkg/cluster"
+	"github.com/yusufcanb/kubot/pkg/result"
+	"github.com/yusufcanb/kubot/pkg/workspace"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+const (
+	collectorContainerName = "output-collector"
+	initContainerName      = "workspace-init"
+
+	dataVolumeName      = "data"
+	archiveVolumeName   = "workspace-archive"
+	archiveKey          = "workspace.tar.gz"
+	archiveMountPath    = "/kubot"
+	collectedMarkerPath = "/data/.collected"
+
+	// maxArchiveSize keeps the config map below the 1MiB object size limit of Kubernetes
+	maxArchiveSize = 1024*1024 - 16*1024
+)
+
+// collectInterval is the polling interval of the pods of a suite job waiting for their outputs to be collected
+var collectInterval = 2 * time.Second
+
+// mergedOutputs are the files produced by rebot
+var mergedOutputs = []string{"output.xml", "log.html", "report.html"}
+
+// Stream ships the workspace archive in a config map and streams the suite outputs back over the exec API,
+// so no persistent volume is needed. Suite pods work on an emptyDir, an init container extracts the archive
+// into it and a collector container keeps the pod alive until the output is downloaded.
+type Stream struct {
+	cluster *cluster.Cluster
+	runID   string
+	pods    PodOptions
+
+	configMap    *corev1.ConfigMap
+	workspaceDir string
+}
+
+// RunID returns the identifier of the run the transfer is created for
+func (it *Stream) RunID() string {
+	return it.runID
+}
+
+// Cluster returns the cluster the suites run on
+func (it *Stream) Cluster() *cluster.Cluster {
+	return it.cluster
+}
+
+// WorkspaceDir returns the path of the workspace root inside the suite pods
+func (it *Stream) WorkspaceDir() string {
+	return it.workspaceDir
+}
+
+// InitDirectories archives the workspace into a config map mounted by the suite pods
+func (it *Stream) InitDirectories(ctx context.Context, w *workspace.Workspace) error {
+	root, err := filepath.Abs(w.Root().Path)
+	if err != nil {
+		return fmt.Errorf("archive workspace: %w", err)
+	}
+
+	archivePath, err := utils.ArchiveWorkspace(&root)
+	if err != nil {
+		return fmt.Errorf("archive workspace: %w", err)
+	}
+	defer os.Remove(archivePath)
+
+	archive, err := os.ReadFile(archivePath)
+	if err != nil {
+		return fmt.Errorf("archive workspace: %w", err)
+	}
+
+	if len(archive) > maxArchiveSize {
+		return fmt.Errorf("workspace archive is %d bytes, larger than the %d bytes a config map can hold. use --transfer=%s", len(archive), maxArchiveSize, TransferVolume)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kubot-workspace-",
+			Labels:       resourceLabels(it.runID, ComponentWorkspace, ""),
+		},
+		BinaryData: map[string][]byte{
+			archiveKey: archive,
+		},
+	}
+
+	configMap, err = it.cluster.Client().CoreV1().ConfigMaps(it.cluster.DefaultNamespace()).Create(ctx, configMap, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create workspace config map: %w", err)
+	}
+
+	it.configMap = configMap
+	it.workspaceDir = path.Join(workspaceRoot, filepath.Base(root))
+	log.Infof("workspace archive of %d bytes is stored in config map %s", len(archive), configMap.Name)
+
+	return nil
+}
+
+// InstallDependencies rejects dependencies, the pods have no shared storage and every one of them would have to
+// install the dependencies from the package index
+func (it *Stream) InstallDependencies(ctx context.Context, image string) error {
+	if it.pods.Dependencies != nil {
+		return fmt.Errorf("the %s transfer cannot install dependencies, use --transfer=%s", TransferStream, TransferVolume)
+	}
+	return nil
+}
+
+// Destroy deletes the config map, the resources of the run are owned by it
+func (it *Stream) Destroy(ctx context.Context) error {
+	if it.configMap == nil {
+		return nil
+	}
+
+	propagation := metav1.DeletePropagationForeground
+	err := it.cluster.Client().CoreV1().ConfigMaps(it.cluster.DefaultNamespace()).Delete(ctx, it.configMap.Name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil {
+		return err
+	}
+
+	it.configMap = nil
+
+	return nil
+}
+
+func (it *Stream) podSpec(image string, command []string, component string) corev1.PodSpec {
+	dataMount := corev1.VolumeMount{Name: dataVolumeName, MountPath: dataDir}
+
+	container := newContainer(image, command)
+	container.VolumeMounts = []corev1.VolumeMount{dataMount}
+
+	spec := corev1.PodSpec{
+		Containers: []corev1.Container{container},
+		Volumes: []corev1.Volume{
+			{
+				Name:         dataVolumeName,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			},
+		},
+		RestartPolicy: corev1.RestartPolicyNever,
+	}
+
+	// merger pods receive the outputs to merge instead of the workspace
+	if component != ComponentSuite {
+		return spec
+	}
+
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: archiveVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: it.configMap.Name},
+			},
+		},
+	})
+
+	spec.InitContainers = []corev1.Container{
+		{
+			Name:  initContainerName,
+			Image: image,
+			Command: []string{"sh", "-c", fmt.Sprintf("mkdir -p %s %s && tar -xzf %s -C %s",
+				workspaceRoot, outputRoot, path.Join(archiveMountPath, archiveKey), workspaceRoot)},
+			VolumeMounts: []corev1.VolumeMount{
+				dataMount,
+				{Name: archiveVolumeName, MountPath: archiveMountPath, ReadOnly: true},
+			},
+		},
+	}
+
+	// the output is lost when every container of the pod has terminated, the collector
+	// keeps the pod running until kubot has downloaded it
+	spec.Containers = append(spec.Containers, corev1.Container{
+		Name:         collectorContainerName,
+		Image:        image,
+		Command:      []string{"sh", "-c", fmt.Sprintf("while [ ! -f %s ]; do sleep 1; done", collectedMarkerPath)},
+		VolumeMounts: []corev1.VolumeMount{dataMount},
+	})
+
+	return spec
+}
+
+func (it *Stream) podOptions() PodOptions {
+	return it.pods
+}
+
+func (it *Stream) ownerReferences() []metav1.OwnerReference {
+	if it.configMap == nil {
+		return nil
+	}
+
+	return []metav1.OwnerReference{
+		{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Name:       it.configMap.Name,
+			UID:        it.configMap.UID,
+		},
+	}
+}
+
+// wait collects the output of the suite while waiting for the job, the job cannot finish before its output is collected
+func (it *Stream) wait(ctx context.Context, suiteJob *Job, suiteName string) error {
+	collectCtx, stopCollecting := context.WithCancel(ctx)
+	collected := make(chan error, 1)
+	go func() {
+		collected <- it.collect(collectCtx, suiteJob, suiteName)
+	}()
+
+	err := suiteJob.wait(ctx)
+	stopCollecting()
+	collectErr := <-collected
+
+	if err != nil && !errors.Is(err, ErrTestsFailed) {
+		return err
+	}
+	if collectErr != nil {
+		return fmt.Errorf("collecting output of suite %s: %w", suiteName, collectErr)
+	}
+
+	return err
+}
+
+// collect downloads the output of every pod of the job whose robot container has terminated and releases the pod
+// afterwards. Retried pods overwrite the output of the previous ones. It returns when the context is done.
+func (it *Stream) collect(ctx context.Context, suiteJob *Job, suiteName string) error {
+	localDir := filepath.Join(LocalOutputDir, filepath.FromSlash(suiteName))
+	collected := make(map[string]bool)
+	var lastErr error
+
+	for {
+		pods, err := suiteJob.pods(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Debugf("listing pods of suite %s: %s", suiteName, err)
+		}
+
+		for i := range pods {
+			pod := &pods[i]
+			if collected[pod.Name] || !outputReady(pod) {
+				continue
+			}
+			collected[pod.Name] = true
+
+			lastErr = download(ctx, it.cluster, pod, collectorContainerName, path.Join(outputRoot, suiteName), []string{"."}, localDir)
+
+			// the pod is released even if the download has failed, it would run forever otherwise
+			err = it.cluster.Executor().Exec(ctx, pod, collectorContainerName, []string{"touch", collectedMarkerPath}, cluster.Streams{})
+			if err != nil {
+				log.Errorf("failed to release pod %s/%s: %s", pod.Namespace, pod.Name, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return lastErr
+		case <-time.After(collectInterval):
+		}
+	}
+}
+
+// outputReady tells whether robot has finished in the pod and the collector is still waiting
+func outputReady(pod *corev1.Pod) bool {
+	robotDone, collectorRunning := false, false
+	for _, status := range pod.Status.ContainerStatuses {
+		switch status.Name {
+		case containerName:
+			robotDone = status.State.Terminated != nil
+		case collectorContainerName:
+			collectorRunning = status.State.Running != nil
+		}
+	}
+
+	return robotDone && collectorRunning
+}
+
+// rerunArgs selects the failed tests with --test, the previous output is not available in the new pod
+// to use --rerunfailed
+func (it *Stream) rerunArgs(output string) ([]string, error) {
+	previous, err := result.ParseFile(filepath.Join(LocalOutputDir, filepath.FromSlash(output)))
+	if err != nil {
+		return nil, err
+	}
+
+	failed := previous.FailedTests()
+	if len(failed) == 0 {
+		return nil, fmt.Errorf("%s has no failed tests to rerun", output)
+	}
+
+	args := make([]string, 0, len(failed)*2)
+	for _, test := range failed {
+		args = append(args, "--test", escapePattern(test))
+	}
+
+	return args, nil
+}
+
+// merge runs rebot locally when it is installed, in a merger pod otherwise
+func (it *Stream) merge(ctx context.Context, image string, options []string, outputs []string) error {
+	rebot, err := exec.LookPath("rebot")
+	if err != nil {
+		log.Info("rebot is not installed, merging the results in a pod")
+		return it.mergeInPod(ctx, image, options, outputs)
+	}
+
+	return mergeLocally(ctx, rebot, options, outputs)
+}
+
+func (it *Stream) mergeInPod(ctx context.Context, image string, options []string, outputs []string) error {
+	mergerPod, err := NewSuitePod(ctx, it, image, ComponentMerger)
+	if err != nil {
+		return err
+	}
+	defer mergerPod.destroy(ctx)
+
+	err = upload(ctx, it.cluster, mergerPod.pod, containerName, LocalOutputDir, outputs, outputRoot)
+	if err != nil {
+		return err
+	}
+
+	cmd := append([]string{"rebot"}, options...)
+	cmd = append(cmd, "--outputdir", outputRoot)
+	for _, output := range outputs {
+		cmd = append(cmd, path.Join(outputRoot, output))
+	}
+
+	err = mergerPod.exec(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("merger failed: %s", err)
+	}
+
+	return download(ctx, it.cluster, mergerPod.pod, containerName, outputRoot, mergedOutputs, LocalOutputDir)
+}
+
+// NewStream creates the stream transfer of a run, the pod options apply to the suite and merger pods
+func NewStream(c *cluster.Cluster, runID string, pods PodOptions) *Stream {
+	return &Stream{
+		cluster: c,
+		runID:   runID,
+		pods:    pods,
+	}
+}
@@ -0,0 +1,123 @@
+package suite
+
+import (
+	"context"
+	"github.com/yusufcanb/kubot/pkg/cluster/fake"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStream_PodSpec(t *testing.T) {
+	s := NewStream(nil, "run", PodOptions{})
+	s.configMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "kubot-workspace-abcde"}}
+
+	spec := s.podSpec("robot", []string{"robot"}, ComponentSuite)
+	if len(spec.InitContainers) != 1 || len(spec.Containers) != 2 || spec.Containers[1].Name != collectorContainerName {
+		t.Errorf("suite pod spec got = %+v, want an init container and a collector", spec)
+	}
+	if len(spec.Volumes) != 2 || spec.Volumes[1].ConfigMap.Name != "kubot-workspace-abcde" {
+		t.Errorf("suite pod spec volumes got = %+v", spec.Volumes)
+	}
+
+	spec = s.podSpec("robot", []string{"sleep", "infinity"}, ComponentMerger)
+	if len(spec.InitContainers) != 0 || len(spec.Containers) != 1 || len(spec.Volumes) != 1 {
+		t.Errorf("merger pod spec got = %+v, want only the emptyDir", spec)
+	}
+}
+
+func TestStream_InstallDependencies(t *testing.T) {
+	s := NewStream(nil, "run", PodOptions{})
+	if err := s.InstallDependencies(context.Background(), "robot"); err != nil {
+		t.Errorf("InstallDependencies() error = %v, want nil without dependencies", err)
+	}
+
+	// every suite pod would install them from the package index
+	s = NewStream(nil, "run", PodOptions{Dependencies: &Dependencies{file: RequirementsFile}})
+	if err := s.InstallDependencies(context.Background(), "robot"); err == nil {
+		t.Error("InstallDependencies() error = nil, want the stream transfer rejected")
+	}
+}
+
+func TestStream_InitDirectories(t *testing.T) {
+	c, client, _ := fake.NewCluster("kubot")
+	ctx := context.Background()
+
+	s := NewStream(c, "run-1", PodOptions{})
+	if err := s.InitDirectories(ctx, newTestWorkspace(t, "google.robot")); err != nil {
+		t.Fatal(err)
+	}
+
+	configMaps, _ := client.CoreV1().ConfigMaps("kubot").List(ctx, metav1.ListOptions{})
+	if len(configMaps.Items) != 1 || len(configMaps.Items[0].BinaryData[archiveKey]) == 0 || configMaps.Items[0].Labels[LabelRunID] != "run-1" {
+		t.Fatalf("config maps got = %+v, want the labelled workspace archive", configMaps.Items)
+	}
+	if s.WorkspaceDir() != "/data/workspace/scripts" {
+		t.Errorf("WorkspaceDir() got = %v", s.WorkspaceDir())
+	}
+
+	if err := s.Destroy(ctx); err != nil {
+		t.Fatal(err)
+	}
+	configMaps, _ = client.CoreV1().ConfigMaps("kubot").List(ctx, metav1.ListOptions{})
+	if len(configMaps.Items) != 0 {
+		t.Errorf("config maps got = %d after Destroy(), want 0", len(configMaps.Items))
+	}
+}
+
+func TestStream_Wait(t *testing.T) {
+	defer func(interval time.Duration) { collectInterval = interval }(collectInterval)
+	collectInterval = 10 * time.Millisecond
+	chdir(t, t.TempDir())
+
+	c, client, executor := fake.NewCluster("kubot")
+	ctx := context.Background()
+
+	s := NewStream(c, "run-1", PodOptions{})
+	if err := s.InitDirectories(ctx, newTestWorkspace(t, "admin/users.robot")); err != nil {
+		t.Fatal(err)
+	}
+
+	suiteJob, err := NewSuiteJob(ctx, s, "robot", "admin/users.robot", []string{"robot"}, JobOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: suiteJob.job.Name + "-pod", Namespace: "kubot", Labels: suiteJob.job.Spec.Template.Labels},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: containerName, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}},
+				{Name: collectorContainerName, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+	if _, err := client.CoreV1().Pods("kubot").Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	executor.Files["/data/output/admin/users.robot/output.xml"] = "<robot/>"
+
+	// like the real job, it only completes after the collector is released
+	executor.ExecError = func(cmd []string) error {
+		if cmd[0] != "touch" {
+			return nil
+		}
+		job := suiteJob.job.DeepCopy()
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		_, err := client.BatchV1().Jobs("kubot").UpdateStatus(ctx, job, metav1.UpdateOptions{})
+		return err
+	}
+
+	if err := s.wait(ctx, suiteJob, "admin/users.robot"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(LocalOutputDir, "admin", "users.robot", "output.xml"))
+	if err != nil || string(content) != "<robot/>" {
+		t.Errorf("collected output got = %q, %v", content, err)
+	}
+}
@@ -0,0 +1,170 @@
+package suite
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/yusufcanb/kubot/pkg/cluster"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// upload copies the given files under localDir into remoteDir of the pod, keeping their relative paths
+func upload(ctx context.Context, c *cluster.Cluster, pod *corev1.Pod, container string, localDir string, names []string, remoteDir string) error {
+	archive, err := os.CreateTemp("", "kubot-*.tar")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(archive.Name())
+
+	err = writeTar(archive, localDir, names)
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %v", localDir, err)
+	}
+
+	return c.Executor().Upload(ctx, pod, container, archive.Name(), remoteDir)
+}
+
+// download copies the given files under remoteDir of the pod into localDir, keeping their relative paths
+func download(ctx context.Context, c *cluster.Cluster, pod *corev1.Pod, container string, remoteDir string, names []string, localDir string) error {
+	archive, err := os.CreateTemp("", "kubot-*.tar")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	err = c.Executor().Download(ctx, pod, container, remoteDir, names, archive.Name())
+	if err != nil {
+		return err
+	}
+
+	// the archive is only extracted after its checksum is verified
+	err = readTar(archive, localDir)
+	if err != nil {
+		return fmt.Errorf("extract %s/%s:%s: %v", pod.Namespace, pod.Name, remoteDir, err)
+	}
+
+	return nil
+}
+
+// writeTar writes the given files or directories under root into a tar stream
+func writeTar(w io.Writer, root string, names []string) error {
+	tarWriter := tar.NewWriter(w)
+
+	for _, name := range names {
+		err := filepath.Walk(filepath.Join(root, filepath.FromSlash(name)), func(filePath string, fileInfo os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !fileInfo.Mode().IsRegular() && !fileInfo.IsDir() {
+				return nil
+			}
+
+			header, err := tar.FileInfoHeader(fileInfo, "")
+			if err != nil {
+				return err
+			}
+
+			relPath, err := filepath.Rel(root, filePath)
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(relPath)
+
+			if err = tarWriter.WriteHeader(header); err != nil {
+				return err
+			}
+			if fileInfo.IsDir() {
+				return nil
+			}
+
+			file, err := os.Open(filePath)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			_, err = io.Copy(tarWriter, file)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tarWriter.Close()
+}
+
+// readTar extracts a tar stream into dst. Entries escaping dst are rejected, links and special files are skipped.
+func readTar(r io.Reader, dst string) error {
+	tarReader := tar.NewReader(r)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := extractPath(dst, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tarReader, target, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			log.Debugf("skipping %s, unsupported tar entry type %c", header.Name, header.Typeflag)
+		}
+	}
+}
+
+// extractPath returns the path of a tar entry inside dst, failing for absolute names and names escaping dst
+func extractPath(dst string, name string) (string, error) {
+	if strings.HasPrefix(name, "/") || filepath.IsAbs(name) {
+		return "", fmt.Errorf("tar entry %q has an absolute path", name)
+	}
+
+	target := filepath.Join(dst, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dst, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("tar entry %q is outside of the target directory", name)
+	}
+
+	return target, nil
+}
+
+func extractFile(r io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
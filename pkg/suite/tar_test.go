@@ -0,0 +1,87 @@
+package suite
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTar_RoundTrip(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"a.robot/output.xml":             "<robot/>",
+		"admin/users.robot/output.xml":   "<robot>users</robot>",
+		"admin/users.robot/rerun-1.xml":  "<robot>rerun</robot>",
+		"search.robot/not-included.html": "<html/>",
+	}
+	for name, content := range files {
+		target := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeTar(buf, src, []string{"a.robot/output.xml", "admin"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := t.TempDir()
+	if err := readTar(buf, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		got, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+		if name == "search.robot/not-included.html" {
+			if !os.IsNotExist(err) {
+				t.Errorf("%s should not be extracted", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("%s got = %v, want %v", name, string(got), content)
+		}
+	}
+}
+
+func TestReadTar_PathTraversal(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "output.xml", wantErr: false},
+		{name: "./suite.robot/output.xml", wantErr: false},
+		{name: "../output.xml", wantErr: true},
+		{name: "suite.robot/../../output.xml", wantErr: true},
+		{name: "/etc/passwd", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			tarWriter := tar.NewWriter(buf)
+			if err := tarWriter.WriteHeader(&tar.Header{Name: tt.name, Mode: 0644, Size: 1, Typeflag: tar.TypeReg}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tarWriter.Write([]byte("x")); err != nil {
+				t.Fatal(err)
+			}
+			if err := tarWriter.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			err := readTar(buf, t.TempDir())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readTar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package suite
+
+import (
+	"encoding/json"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"os"
+	"sigs.k8s.io/yaml"
+)
+
+// PodTemplate is a partial pod spec merged into the specs of the pods created by kubot, e.g. node selectors,
+// tolerations, affinity or the service account. Lists are merged the way kubectl apply does, containers by name.
+type PodTemplate struct {
+	patch []byte // strategic merge patch of the pod spec
+}
+
+// apply merges the template into the spec, a nil template returns the spec as is
+func (it *PodTemplate) apply(spec corev1.PodSpec) (corev1.PodSpec, error) {
+	if it == nil {
+		return spec, nil
+	}
+
+	original, err := json.Marshal(spec)
+	if err != nil {
+		return spec, err
+	}
+
+	merged, err := strategicpatch.StrategicMergePatch(original, it.patch, corev1.PodSpec{})
+	if err != nil {
+		return spec, fmt.Errorf("apply pod template: %v", err)
+	}
+
+	var result corev1.PodSpec
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return spec, fmt.Errorf("apply pod template: %v", err)
+	}
+
+	return result, nil
+}
+
+// validate rejects the fields kubot relies on, a template adding containers or restarting them
+// would keep the suite pods from ever finishing
+func (it *PodTemplate) validate(spec corev1.PodSpec) error {
+	for _, container := range spec.Containers {
+		if container.Name != containerName {
+			return fmt.Errorf("pod template can only change the %s container, got %q", containerName, container.Name)
+		}
+	}
+	if spec.RestartPolicy != "" && spec.RestartPolicy != corev1.RestartPolicyNever {
+		return fmt.Errorf("pod template cannot change the restart policy")
+	}
+
+	_, err := it.apply(corev1.PodSpec{Containers: []corev1.Container{{Name: containerName}}})
+	return err
+}
+
+// ParsePodTemplate parses a partial pod spec in YAML or JSON, unknown fields are rejected
+func ParsePodTemplate(data []byte) (*PodTemplate, error) {
+	var spec corev1.PodSpec
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid pod template: %v", err)
+	}
+
+	patch, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pod template: %v", err)
+	}
+
+	template := &PodTemplate{patch: patch}
+	if err := template.validate(spec); err != nil {
+		return nil, fmt.Errorf("invalid pod template: %v", err)
+	}
+
+	return template, nil
+}
+
+// LoadPodTemplate reads the pod template file at the given path
+func LoadPodTemplate(path string) (*PodTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read pod template: %v", err)
+	}
+
+	return ParsePodTemplate(data)
+}
@@ -0,0 +1,96 @@
+package suite
+
+import (
+	"context"
+	"github.com/yusufcanb/kubot/pkg/cluster/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+const browserTemplate = `
+serviceAccountName: browser-runner
+priorityClassName: e2e
+nodeSelector:
+  pool: browsers
+tolerations:
+  - key: dedicated
+    operator: Equal
+    value: browsers
+    effect: NoSchedule
+imagePullSecrets:
+  - name: registry
+containers:
+  - name: job-container
+    volumeMounts:
+      - name: dshm
+        mountPath: /dev/shm
+volumes:
+  - name: dshm
+    emptyDir:
+      medium: Memory
+`
+
+func TestParsePodTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{name: "valid", template: browserTemplate},
+		{name: "unknown field", template: "nodeSelectors:\n  pool: browsers\n", wantErr: true},
+		{name: "extra container", template: "containers:\n  - name: sidecar\n    image: envoy\n", wantErr: true},
+		{name: "restart policy", template: "restartPolicy: OnFailure\n", wantErr: true},
+		{name: "not a pod spec", template: "- pool\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePodTemplate([]byte(tt.template))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePodTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSuiteJob_PodTemplate(t *testing.T) {
+	template, err := ParsePodTemplate([]byte(browserTemplate))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, client, _ := fake.NewCluster("kubot")
+	ctx := context.Background()
+	v, err := NewVolume(ctx, c, "run-1", VolumeOptions{Size: "1Gi", Pods: PodOptions{Template: template}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewSuiteJob(ctx, v, "robot", "google.robot", []string{"robot"}, JobOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, _ := client.BatchV1().Jobs("kubot").List(ctx, metav1.ListOptions{})
+	if len(jobs.Items) != 1 {
+		t.Fatalf("jobs got = %d, want 1", len(jobs.Items))
+	}
+	spec := jobs.Items[0].Spec.Template.Spec
+
+	if spec.ServiceAccountName != "browser-runner" || spec.PriorityClassName != "e2e" || spec.NodeSelector["pool"] != "browsers" {
+		t.Errorf("pod spec got = %+v, want the template fields", spec)
+	}
+	if len(spec.Tolerations) != 1 || len(spec.ImagePullSecrets) != 1 {
+		t.Errorf("tolerations got = %v, image pull secrets got = %v", spec.Tolerations, spec.ImagePullSecrets)
+	}
+	if len(spec.Volumes) != 2 || spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("volumes got = %v, want the template volume next to the suite volume", spec.Volumes)
+	}
+
+	container := spec.Containers[0]
+	if len(spec.Containers) != 1 || container.Image != "robot" || len(container.VolumeMounts) != 2 {
+		t.Errorf("container got = %+v, want the template mounts merged into the robot container", container)
+	}
+	if container.Resources.Limits.Memory().IsZero() {
+		t.Errorf("container resources got = %v, want the kubot resources kept", container.Resources)
+	}
+}
@@ -0,0 +1,84 @@
+package suite
+
+import (
+	"context"
+	"github.com/yusufcanb/kubot/pkg/cluster"
+	"github.com/yusufcanb/kubot/pkg/workspace"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PodOptions configures the pods created by a transfer
+type PodOptions struct {
+	Template  *PodTemplate      // merged into the pod specs, nil for none
+	Resources *ResourceProfiles // resources of the robot container, nil for the default profile only
+	Env       *Env              // environment of the robot container, nil for none
+
+	Dependencies *Dependencies // installed into a virtualenv robot runs in, nil for none
+}
+
+// newPodSpec creates the spec of a pod of the given component, the robot container gets the resources of the profile
+// and the environment, then the pod template of the transfer is merged into the spec
+func newPodSpec(t Transfer, image string, command []string, component string, profile string) (corev1.PodSpec, error) {
+	options := t.podOptions()
+
+	resources, err := options.Resources.requirements(profile)
+	if err != nil {
+		return corev1.PodSpec{}, err
+	}
+
+	spec := t.podSpec(image, command, component)
+	for i := range spec.Containers {
+		if spec.Containers[i].Name == containerName {
+			spec.Containers[i].Resources = resources
+			if options.Env != nil {
+				spec.Containers[i].Env = options.Env.vars
+				spec.Containers[i].EnvFrom = options.Env.envFrom
+			}
+		}
+	}
+
+	return options.Template.apply(spec)
+}
+
+const (
+	TransferVolume = "volume" // workspace and outputs are shared through a persistent volume claim
+	TransferStream = "stream" // workspace is shipped in a config map and outputs are streamed back
+)
+
+// paths inside the suite pods, the same for every transfer mode
+const (
+	dataDir       = "/data"
+	workspaceRoot = "/data/workspace"
+	outputRoot    = "/data/output"
+)
+
+// Transfer ships the workspace into the suite pods and brings their outputs back.
+// Output paths passed to a transfer are relative to the output root, e.g. admin/users.robot/output.xml
+type Transfer interface {
+	// RunID returns the identifier of the run the transfer is created for
+	RunID() string
+	// Cluster returns the cluster the suites run on
+	Cluster() *cluster.Cluster
+	// WorkspaceDir returns the path of the workspace root inside the suite pods
+	WorkspaceDir() string
+	// InitDirectories ships the workspace
+	InitDirectories(ctx context.Context, w *workspace.Workspace) error
+	// InstallDependencies installs the dependencies of the pod options with the suite image, if any
+	InstallDependencies(ctx context.Context, image string) error
+	// Destroy deletes the resources of the transfer along with the resources owned by them
+	Destroy(ctx context.Context) error
+
+	// podSpec creates the spec of a pod of the given component running the command
+	podSpec(image string, command []string, component string) corev1.PodSpec
+	// podOptions returns the template, the resource profiles, the environment and the dependencies of the pods
+	podOptions() PodOptions
+	// ownerReferences returns the owners of the resources created for the run
+	ownerReferences() []metav1.OwnerReference
+	// wait waits for the suite job to finish and makes its output available for merging
+	wait(ctx context.Context, suiteJob *Job, suiteName string) error
+	// rerunArgs returns the robot arguments selecting the failed tests of the given output
+	rerunArgs(output string) ([]string, error)
+	// merge runs rebot with the given options over the outputs and downloads the merged results into LocalOutputDir
+	merge(ctx context.Context, image string, options []string, outputs []string) error
+}
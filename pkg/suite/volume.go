@@ -2,22 +2,107 @@ package suite
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	log "github.com/sirupsen/logrus"
 	"github.com/yusufcanb/kubot/pkg/cluster"
 	"github.com/yusufcanb/kubot/pkg/workspace"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
+// LocalOutputDir is the directory the merged results are downloaded into
+const LocalOutputDir = ".kubot"
+
+// annotationDefaultStorageClass marks the default storage class of the cluster
+const annotationDefaultStorageClass = "storageclass.kubernetes.io/is-default-class"
+
+// claimPollInterval is the polling interval of the claim waiting to be bound
+var claimPollInterval = 5 * time.Second
+
+var accessModeAliases = map[string]corev1.PersistentVolumeAccessMode{
+	"rwo":  corev1.ReadWriteOnce,
+	"rox":  corev1.ReadOnlyMany,
+	"rwx":  corev1.ReadWriteMany,
+	"rwop": corev1.ReadWriteOncePod,
+}
+
+// VolumeOptions configures the claim of the suite volume
+type VolumeOptions struct {
+	StorageClass string   // empty means the default storage class of the cluster
+	Size         string   // e.g. 1Gi
+	AccessModes  []string // e.g. ReadWriteMany or RWX
+
+	Pods PodOptions // template and resources of the pods mounting the volume
+}
+
+func (it VolumeOptions) accessModes() ([]corev1.PersistentVolumeAccessMode, error) {
+	modes := make([]corev1.PersistentVolumeAccessMode, 0, len(it.AccessModes))
+	for _, value := range it.AccessModes {
+		if mode, ok := accessModeAliases[strings.ToLower(value)]; ok {
+			modes = append(modes, mode)
+			continue
+		}
+
+		mode := corev1.PersistentVolumeAccessMode(value)
+		switch mode {
+		case corev1.ReadWriteOnce, corev1.ReadOnlyMany, corev1.ReadWriteMany, corev1.ReadWriteOncePod:
+			modes = append(modes, mode)
+		default:
+			return nil, fmt.Errorf("invalid volume access mode %q", value)
+		}
+	}
+
+	if len(modes) == 0 {
+		modes = append(modes, corev1.ReadWriteOnce)
+	}
+
+	return modes, nil
+}
+
+// claimSpec validates the options and converts them into a claim spec
+func (it VolumeOptions) claimSpec() (corev1.PersistentVolumeClaimSpec, error) {
+	spec := corev1.PersistentVolumeClaimSpec{}
+
+	size, err := resource.ParseQuantity(it.Size)
+	if err != nil {
+		return spec, fmt.Errorf("invalid volume size %q: %v", it.Size, err)
+	}
+
+	spec.AccessModes, err = it.accessModes()
+	if err != nil {
+		return spec, err
+	}
+
+	if it.StorageClass != "" {
+		storageClassName := it.StorageClass
+		spec.StorageClassName = &storageClassName
+	}
+
+	spec.Resources = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceStorage: size,
+		},
+	}
+
+	return spec, nil
+}
+
 type Volume struct {
 	cluster *cluster.Cluster
+	runID   string
+	options VolumeOptions
 
-	volume  *corev1.Volume // volume to extract workspace into
-	initPod *Pod
+	claim *corev1.PersistentVolumeClaim
+
+	volume       *corev1.Volume // volume to extract workspace into
+	initPod      *Pod
+	workspaceDir string // workspace root directory inside the volume
 }
 
 func (it *Volume) Exists() bool {
@@ -27,31 +112,26 @@ func (it *Volume) Exists() bool {
 	return false
 }
 
-func (it *Volume) Create() error {
-	size := "1Gi"
-	accessModes := []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
-	storageClassName := "azurefile-premium"
+func (it *Volume) Create(ctx context.Context) error {
+	spec, err := it.options.claimSpec()
+	if err != nil {
+		return err
+	}
 
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "pvc-kubot-",
+			Labels:       resourceLabels(it.runID, ComponentVolume, ""),
 		},
-		Spec: corev1.PersistentVolumeClaimSpec{
-			StorageClassName: &storageClassName,
-			AccessModes:      accessModes,
-			Resources: corev1.ResourceRequirements{
-				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: resource.MustParse(size),
-				},
-			},
-		},
+		Spec: spec,
 	}
 
-	pvc, err := it.cluster.Client().CoreV1().PersistentVolumeClaims(it.cluster.DefaultNamespace()).Create(context.Background(), pvc, metav1.CreateOptions{})
+	pvc, err = it.cluster.Client().CoreV1().PersistentVolumeClaims(it.cluster.DefaultNamespace()).Create(ctx, pvc, metav1.CreateOptions{})
 	if err != nil {
 		return err
 	}
 
+	it.claim = pvc
 	it.volume = &corev1.Volume{
 		Name: pvc.ObjectMeta.Name,
 		VolumeSource: corev1.VolumeSource{
@@ -63,7 +143,7 @@ func (it *Volume) Create() error {
 
 	// Wait until the volume is Bound
 	for {
-		pvc, err = it.cluster.Client().CoreV1().PersistentVolumeClaims(it.cluster.DefaultNamespace()).Get(context.Background(), pvc.ObjectMeta.Name, metav1.GetOptions{})
+		pvc, err = it.cluster.Client().CoreV1().PersistentVolumeClaims(it.cluster.DefaultNamespace()).Get(ctx, pvc.ObjectMeta.Name, metav1.GetOptions{})
 		if err != nil {
 			return err
 		}
@@ -72,73 +152,284 @@ func (it *Volume) Create() error {
 			break
 		}
 
-		time.Sleep(5 * time.Second)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for volume %s to be bound: %w", pvc.ObjectMeta.Name, ctx.Err())
+		case <-time.After(claimPollInterval):
+		}
 	}
 
 	return nil
 }
 
-func (it *Volume) Destroy() error {
+func (it *Volume) Destroy(ctx context.Context) error {
+	if it.initPod != nil {
+		err := it.initPod.destroy(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	if it.volume == nil {
 		return nil
 	}
 
-	err := it.cluster.Client().CoreV1().PersistentVolumeClaims(it.cluster.DefaultNamespace()).Delete(context.Background(), it.volume.Name, metav1.DeleteOptions{})
+	// the pods and jobs of the run are owned by the claim, foreground deletion removes them first
+	propagation := metav1.DeletePropagationForeground
+	err := it.cluster.Client().CoreV1().PersistentVolumeClaims(it.cluster.DefaultNamespace()).Delete(ctx, it.volume.Name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
 	if err != nil {
 		return err
 	}
 
 	it.volume = nil
+	it.claim = nil
+
+	return nil
+}
 
-	err = it.initPod.destroy()
+func (it *Volume) InitDirectories(ctx context.Context, w *workspace.Workspace) error {
+	suitePod, err := NewSuitePod(ctx, it, "docker.io/ubuntu:bionic", ComponentVolume)
 	if err != nil {
-		return err
+		return fmt.Errorf("init directories: %w", err)
 	}
+	it.initPod = suitePod
+
+	err = suitePod.exec(ctx, []string{"mkdir", workspaceRoot, outputRoot})
+	if err != nil {
+		return fmt.Errorf("init directories: %s", err)
+	}
+
+	root, err := filepath.Abs(w.Root().Path)
+	if err != nil {
+		return fmt.Errorf("copy workspace: %w", err)
+	}
+
+	err = suitePod.copy(ctx, root, workspaceRoot+"/")
+	if err != nil {
+		return fmt.Errorf("copy workspace: %w", err)
+	}
+
+	it.workspaceDir = path.Join(workspaceRoot, filepath.Base(root))
 
 	return nil
 }
 
-func (it *Volume) InitDirectories(w *workspace.Workspace) error {
-	suitePod, err := NewSuitePod(it, "docker.io/ubuntu:bionic")
+// InstallDependencies installs the dependencies of the workspace once into the virtualenv on the volume, the suite pods
+// share it. The pod runs the suite image, so the virtualenv has the python of the suites.
+func (it *Volume) InstallDependencies(ctx context.Context, image string) error {
+	deps := it.options.Pods.Dependencies
+	if deps == nil {
+		return nil
+	}
+
+	installPod, err := NewSuitePod(ctx, it, image, ComponentDependencies)
 	if err != nil {
-		return errors.New(fmt.Sprintf("init directories: ", err))
+		return fmt.Errorf("install dependencies: %w", err)
 	}
+	defer func() {
+		// the context may be cancelled by an interruption during the install
+		cleanupCtx, cancel := CleanupContext()
+		defer cancel()
+		if err := installPod.destroy(cleanupCtx); err != nil {
+			log.Errorf("failed to delete dependencies pod %s: %s", installPod.pod.Name, err)
+		}
+	}()
 
-	err = suitePod.exec([]string{"mkdir", "/data/workspace", "/data/output", "/data/console"})
+	err = installPod.exec(ctx, []string{"sh", "-c", deps.installScript(it.workspaceDir)})
 	if err != nil {
-		return errors.New(fmt.Sprintf("init directories: ", err))
+		return fmt.Errorf("install dependencies of %s: %s", deps.File(), err)
 	}
 
-	err = suitePod.copy(w.Root().Path, "/data/workspace/")
+	return nil
+}
+
+// RunID returns the identifier of the run the volume is created for
+func (it *Volume) RunID() string {
+	return it.runID
+}
+
+// Cluster returns the cluster the suites run on
+func (it *Volume) Cluster() *cluster.Cluster {
+	return it.cluster
+}
+
+func (it *Volume) podSpec(image string, command []string, component string) corev1.PodSpec {
+	container := newContainer(image, command)
+	container.VolumeMounts = []corev1.VolumeMount{
+		{
+			Name:      it.volume.Name,
+			MountPath: dataDir,
+		},
+	}
+
+	return corev1.PodSpec{
+		Containers: []corev1.Container{container},
+		Volumes: []corev1.Volume{
+			{
+				Name: it.volume.Name,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: it.volume.Name,
+					},
+				},
+			},
+		},
+		RestartPolicy: corev1.RestartPolicyNever,
+	}
+}
+
+func (it *Volume) podOptions() PodOptions {
+	return it.options.Pods
+}
+
+// wait waits for the suite job, robot writes the output into the volume directly
+func (it *Volume) wait(ctx context.Context, suiteJob *Job, suiteName string) error {
+	return suiteJob.wait(ctx)
+}
+
+func (it *Volume) rerunArgs(output string) ([]string, error) {
+	return []string{"--rerunfailed", path.Join(outputRoot, output)}, nil
+}
+
+// merge runs rebot in a merger pod mounting the volume and downloads the results
+func (it *Volume) merge(ctx context.Context, image string, options []string, outputs []string) error {
+	mergerPod, err := NewSuitePod(ctx, it, image, ComponentMerger)
 	if err != nil {
-		return errors.New(fmt.Sprintf("copy workspace: %s", err))
+		return err
 	}
 
-	it.initPod = suitePod
+	cmd := append([]string{"rebot"}, options...)
+	cmd = append(cmd, "--outputdir", outputRoot)
+	for _, output := range outputs {
+		cmd = append(cmd, path.Join(outputRoot, output))
+	}
 
-	return nil
+	err = mergerPod.exec(ctx, cmd)
+	defer mergerPod.destroy(ctx)
+	if err != nil {
+		return fmt.Errorf("merger failed: %s", err)
+	}
+
+	return it.DownloadOutput(ctx)
 }
 
-func (it *Volume) DownloadOutput() error {
+// ownerReferences makes the claim the owner of the resources of the run, so they are garbage
+// collected along with it even if kubot could not delete them.
+func (it *Volume) ownerReferences() []metav1.OwnerReference {
+	if it.claim == nil {
+		return nil
+	}
+
+	return []metav1.OwnerReference{
+		{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+			Name:       it.claim.Name,
+			UID:        it.claim.UID,
+		},
+	}
+}
 
-	cmd := exec.Command("kubectl", "cp", fmt.Sprintf("%s:%s", it.initPod.pod.Name, "/data/output/"), ".kubot/", "-n", it.cluster.DefaultNamespace())
+// WorkspaceDir returns the path of the workspace root inside the volume
+func (it *Volume) WorkspaceDir() string {
+	return it.workspaceDir
+}
 
-	// Run the command and capture the output and error streams
-	output, err := cmd.CombinedOutput()
+// DownloadOutput copies the output directory of the volume into LocalOutputDir
+func (it *Volume) DownloadOutput(ctx context.Context) error {
+	return download(ctx, it.cluster, it.initPod.pod, containerName, outputRoot, []string{"."}, LocalOutputDir)
+}
+
+// Preflight warns about volume options which would leave suite pods pending. ReadWriteOnce claims can only be
+// mounted on a single node, so concurrent suites scheduled on other nodes would never start.
+func Preflight(ctx context.Context, c *cluster.Cluster, options VolumeOptions, concurrency int) error {
+	accessModes, err := options.accessModes()
+	if err != nil {
+		return err
+	}
+
+	if _, err := resource.ParseQuantity(options.Size); err != nil {
+		return fmt.Errorf("invalid volume size %q: %v", options.Size, err)
+	}
+
+	if options.StorageClass == "" {
+		classes, err := c.Client().StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Debugf("skipping storage class check: %s", err)
+		} else if !hasDefaultStorageClass(classes.Items) {
+			log.Warn("no storage class is given and the cluster has no default storage class, the suite volume may never be bound. use --storage-class")
+		}
+	}
+
+	multiNodeMode := false
+	for _, mode := range accessModes {
+		if mode == corev1.ReadWriteMany || mode == corev1.ReadOnlyMany {
+			multiNodeMode = true
+		}
+	}
+	if multiNodeMode || concurrency < 2 {
+		return nil
+	}
+
+	nodes, err := c.Client().CoreV1().Nodes().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to copy tar file to pod: %s. Error: %v", string(output), err)
+		log.Debugf("skipping volume access mode check: %s", err)
+		return nil
+	}
+
+	if schedulable := schedulableNodes(nodes.Items); schedulable > 1 {
+		log.Warnf("the suite volume uses %v access mode but suites can be scheduled on %d nodes, "+
+			"pods on other nodes than the first one will not start. use --access-mode=ReadWriteMany with a storage class supporting it",
+			accessModes, schedulable)
 	}
 
 	return nil
 }
 
-func NewVolume(c *cluster.Cluster) (*Volume, error) {
+func hasDefaultStorageClass(classes []storagev1.StorageClass) bool {
+	for _, class := range classes {
+		if class.Annotations[annotationDefaultStorageClass] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+func schedulableNodes(nodes []corev1.Node) int {
+	count := 0
+	for _, node := range nodes {
+		if node.Spec.Unschedulable {
+			continue
+		}
+
+		tainted := false
+		for _, taint := range node.Spec.Taints {
+			if taint.Effect == corev1.TaintEffectNoSchedule || taint.Effect == corev1.TaintEffectNoExecute {
+				tainted = true
+			}
+		}
+		if !tainted {
+			count++
+		}
+	}
+	return count
+}
+
+func NewVolume(ctx context.Context, c *cluster.Cluster, runID string, options VolumeOptions) (*Volume, error) {
 	v := Volume{
 		cluster: c,
+		runID:   runID,
+		options: options,
 	}
 
-	err := v.Create()
+	err := v.Create(ctx)
 	if err != nil {
+		// a claim waiting to be bound would be left behind otherwise
+		cleanupCtx, cancel := CleanupContext()
+		defer cancel()
+		_ = v.Destroy(cleanupCtx)
 		return nil, err
 	}
 
@@ -0,0 +1,198 @@
+package suite
+
+import (
+	"context"
+	"github.com/yusufcanb/kubot/pkg/cluster/fake"
+	"github.com/yusufcanb/kubot/pkg/workspace"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestWorkspace creates a workspace directory named scripts with the given files
+func newTestWorkspace(t *testing.T, files ...string) *workspace.Workspace {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "scripts")
+	for _, name := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("*** Test Cases ***\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := workspace.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// chdir changes the working directory for the test, local outputs are written relative to it
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func TestVolumeOptions_ClaimSpec(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      VolumeOptions
+		storageClass *string
+		accessModes  []corev1.PersistentVolumeAccessMode
+		wantErr      bool
+	}{
+		{
+			name:        "cluster default storage class",
+			options:     VolumeOptions{Size: "1Gi"},
+			accessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		},
+		{
+			name:         "storage class and aliases",
+			options:      VolumeOptions{StorageClass: "azurefile-premium", Size: "5Gi", AccessModes: []string{"RWX", "rox"}},
+			storageClass: func() *string { s := "azurefile-premium"; return &s }(),
+			accessModes:  []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany, corev1.ReadOnlyMany},
+		},
+		{
+			name:    "invalid size",
+			options: VolumeOptions{Size: "1 GB"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid access mode",
+			options: VolumeOptions{Size: "1Gi", AccessModes: []string{"ReadWriteSometimes"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := tt.options.claimSpec()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("claimSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(spec.StorageClassName, tt.storageClass) {
+				t.Errorf("claimSpec() storage class = %v, want %v", spec.StorageClassName, tt.storageClass)
+			}
+			if !reflect.DeepEqual(spec.AccessModes, tt.accessModes) {
+				t.Errorf("claimSpec() access modes = %v, want %v", spec.AccessModes, tt.accessModes)
+			}
+			if size := spec.Resources.Requests[corev1.ResourceStorage]; size.String() != tt.options.Size {
+				t.Errorf("claimSpec() size = %v, want %v", size.String(), tt.options.Size)
+			}
+		})
+	}
+}
+
+func TestNewVolume(t *testing.T) {
+	c, client, _ := fake.NewCluster("kubot")
+	ctx := context.Background()
+
+	v, err := NewVolume(ctx, c, "run-1", VolumeOptions{Size: "1Gi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := client.CoreV1().PersistentVolumeClaims("kubot").List(ctx, metav1.ListOptions{LabelSelector: ManagedBySelector()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims.Items) != 1 || claims.Items[0].Labels[LabelRunID] != "run-1" || claims.Items[0].Labels[LabelComponent] != ComponentVolume {
+		t.Fatalf("claims got = %+v, want a labelled claim", claims.Items)
+	}
+	if owners := v.ownerReferences(); len(owners) != 1 || owners[0].Name != claims.Items[0].Name || owners[0].UID == "" {
+		t.Errorf("ownerReferences() got = %+v", owners)
+	}
+
+	if err := v.Destroy(ctx); err != nil {
+		t.Fatal(err)
+	}
+	claims, _ = client.CoreV1().PersistentVolumeClaims("kubot").List(ctx, metav1.ListOptions{})
+	if len(claims.Items) != 0 {
+		t.Errorf("claims got = %d after Destroy(), want 0", len(claims.Items))
+	}
+}
+
+func TestNewVolume_NotBound(t *testing.T) {
+	defer func(interval time.Duration) { claimPollInterval = interval }(claimPollInterval)
+	claimPollInterval = 10 * time.Millisecond
+
+	c, client, _ := fake.NewCluster("kubot")
+	client.PrependReactor("get", "persistentvolumeclaims", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8stesting.GetAction).GetName()
+		return true, &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kubot"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := NewVolume(ctx, c, "run-1", VolumeOptions{Size: "1Gi"})
+	if err == nil {
+		t.Fatal("NewVolume() error = nil, want the claim not bound in time")
+	}
+
+	claims, _ := client.CoreV1().PersistentVolumeClaims("kubot").List(context.Background(), metav1.ListOptions{})
+	if len(claims.Items) != 0 {
+		t.Errorf("claims got = %d, want the pending claim deleted", len(claims.Items))
+	}
+}
+
+func TestVolume_InitDirectories(t *testing.T) {
+	c, client, executor := fake.NewCluster("kubot")
+	ctx := context.Background()
+	w := newTestWorkspace(t, "google.robot", "admin/users.robot")
+
+	v, err := NewVolume(ctx, c, "run-1", VolumeOptions{Size: "1Gi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.InitDirectories(ctx, w); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.WorkspaceDir() != "/data/workspace/scripts" {
+		t.Errorf("WorkspaceDir() got = %v", v.WorkspaceDir())
+	}
+	if got := executor.Executed(); len(got) != 1 || got[0] != "mkdir /data/workspace /data/output" {
+		t.Errorf("executed got = %v", got)
+	}
+	if len(executor.Uploads) != 1 || executor.Uploads[0].RemoteDir != "/data/workspace/" ||
+		!strings.Contains(strings.Join(executor.Uploads[0].Files, ","), "scripts/admin/users.robot") {
+		t.Errorf("uploads got = %+v", executor.Uploads)
+	}
+
+	pods, _ := client.CoreV1().Pods("kubot").List(ctx, metav1.ListOptions{})
+	if len(pods.Items) != 1 || pods.Items[0].Labels[LabelComponent] != ComponentVolume || len(pods.Items[0].OwnerReferences) != 1 {
+		t.Errorf("pods got = %+v, want the labelled init pod owned by the claim", pods.Items)
+	}
+
+	if err := v.Destroy(ctx); err != nil {
+		t.Fatal(err)
+	}
+	pods, _ = client.CoreV1().Pods("kubot").List(ctx, metav1.ListOptions{})
+	claims, _ := client.CoreV1().PersistentVolumeClaims("kubot").List(ctx, metav1.ListOptions{})
+	if len(pods.Items) != 0 || len(claims.Items) != 0 {
+		t.Errorf("got %d pods and %d claims after Destroy(), want none", len(pods.Items), len(claims.Items))
+	}
+}
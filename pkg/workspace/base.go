@@ -1,10 +1,12 @@
 package workspace
 
 import (
-	log "github.com/sirupsen/logrus"
+	"path"
 	"strings"
 )
 
+const suiteExtension = ".robot"
+
 type DirectoryNode struct {
 	Path     string
 	Files    []string
@@ -28,6 +30,34 @@ func (it *Workspace) SubDirectoryNames() []string {
 	return subdirnames
 }
 
+// Suites returns the paths of every robot suite file in the workspace tree, except the ones robot ignores.
+// Paths are slash separated and relative to the workspace root.
+func (it *Workspace) Suites() []string {
+	suites := make([]string, 0)
+	collectSuites(it.Root(), "", &suites)
+	return suites
+}
+
+func collectSuites(node DirectoryNode, dir string, suites *[]string) {
+	for _, file := range node.Files {
+		if strings.HasSuffix(file, suiteExtension) && !ignoredByRobot(file) {
+			*suites = append(*suites, path.Join(dir, file))
+		}
+	}
+
+	for _, child := range node.Children {
+		if !ignoredByRobot(child.Path) {
+			collectSuites(child, path.Join(dir, child.Path), suites)
+		}
+	}
+}
+
+// ignoredByRobot tells whether robot skips the file or directory when it runs a directory, e.g. .venv,
+// _resources or __init__.robot which is not a suite of its own
+func ignoredByRobot(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
 func New(basePath string) (*Workspace, error) {
 
 	var w Workspace
@@ -39,9 +69,5 @@ func New(basePath string) (*Workspace, error) {
 		return nil, err
 	}
 
-	if len(w.Root().Children) > 0 {
-		log.Warnf("Sub-directories [%s] will be discarded. Kubot only supports root directories.", strings.Join(w.SubDirectoryNames()[:], ","))
-	}
-
 	return &w, nil
 }
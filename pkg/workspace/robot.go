@@ -0,0 +1,164 @@
+package workspace
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// TestCase represents a test case (or task) defined in a robot file.
+type TestCase struct {
+	Name string
+	Tags []string
+}
+
+// SuiteFile represents the parts of a robot file kubot cares about.
+type SuiteFile struct {
+	ForceTags   []string
+	DefaultTags []string
+	Tests       []TestCase
+}
+
+var cellSeparator = regexp.MustCompile(`\t+| {2,}`)
+
+// splitCells splits a robot data row into its cells, ignoring comments.
+// Indented rows start with an empty cell.
+func splitCells(line string) []string {
+	line = strings.TrimRight(line, " \t\r")
+
+	var cells []string
+	if trimmed := strings.TrimLeft(line, " \t"); trimmed != line {
+		cells = append(cells, "")
+		line = trimmed
+	}
+
+	for _, cell := range cellSeparator.Split(line, -1) {
+		if strings.HasPrefix(cell, "#") {
+			break
+		}
+		cells = append(cells, strings.TrimSpace(cell))
+	}
+
+	return cells
+}
+
+func sectionOf(header string) string {
+	name := strings.ToLower(strings.Trim(header, "* "))
+	switch name {
+	case "settings", "setting":
+		return "settings"
+	case "test cases", "test case", "tasks", "task":
+		return "tests"
+	default:
+		return name
+	}
+}
+
+func nonEmpty(cells []string) []string {
+	values := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		if cell != "" {
+			values = append(values, cell)
+		}
+	}
+	return values
+}
+
+// ParseSuiteFile reads the test cases and their tags from the given robot file.
+// Only the space separated format is supported.
+func ParseSuiteFile(path string) (*SuiteFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	suite := SuiteFile{}
+	section := ""
+
+	var current *TestCase
+	var currentTagsSet bool
+	var continuation *[]string
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if !currentTagsSet {
+			current.Tags = append(current.Tags, suite.DefaultTags...)
+		}
+		suite.Tests = append(suite.Tests, *current)
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "*") {
+			flush()
+			section = sectionOf(line)
+			continuation = nil
+			continue
+		}
+
+		cells := splitCells(line)
+		if len(nonEmpty(cells)) == 0 {
+			continue
+		}
+
+		switch section {
+		case "settings":
+			if cells[0] == "..." && continuation != nil {
+				*continuation = append(*continuation, nonEmpty(cells[1:])...)
+				continue
+			}
+			continuation = nil
+			switch strings.ToLower(cells[0]) {
+			case "force tags", "test tags", "task tags":
+				suite.ForceTags = append(suite.ForceTags, nonEmpty(cells[1:])...)
+				continuation = &suite.ForceTags
+			case "default tags":
+				suite.DefaultTags = append(suite.DefaultTags, nonEmpty(cells[1:])...)
+				continuation = &suite.DefaultTags
+			}
+		case "tests":
+			if cells[0] != "" {
+				flush()
+				current = &TestCase{Name: cells[0]}
+				currentTagsSet = false
+				continuation = nil
+				cells = append([]string{""}, cells[1:]...)
+			}
+			if current == nil || len(cells) < 2 {
+				continue
+			}
+			if cells[1] == "..." && continuation != nil {
+				*continuation = append(*continuation, nonEmpty(cells[2:])...)
+				continue
+			}
+			continuation = nil
+			if strings.EqualFold(cells[1], "[tags]") {
+				currentTagsSet = true
+				for _, tag := range nonEmpty(cells[2:]) {
+					if strings.ToUpper(tag) != "NONE" {
+						current.Tags = append(current.Tags, tag)
+					}
+				}
+				continuation = &current.Tags
+			}
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range suite.Tests {
+		suite.Tests[i].Tags = append(append([]string{}, suite.ForceTags...), suite.Tests[i].Tags...)
+	}
+
+	return &suite, nil
+}
@@ -0,0 +1,230 @@
+package workspace
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	regexPrefix = "re:"
+	tagPrefix   = "tag:"
+)
+
+// pattern matches a suite by its path relative to the workspace root
+type pattern interface {
+	Match(root string, suite string) (bool, error)
+}
+
+// globPattern matches suite paths and their parent directories against a glob.
+// Besides the standard wildcards, ** matches any number of directories.
+type globPattern struct {
+	re *regexp.Regexp
+}
+
+func (it *globPattern) Match(_ string, suite string) (bool, error) {
+	for p := suite; p != "." && p != "/"; p = path.Dir(p) {
+		if it.re.MatchString(p) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newGlobPattern(glob string) (*globPattern, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid glob %q: unterminated character class", glob)
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %s", glob, err)
+	}
+
+	return &globPattern{re: re}, nil
+}
+
+// regexPattern matches suite paths against a regular expression
+type regexPattern struct {
+	re *regexp.Regexp
+}
+
+func (it *regexPattern) Match(_ string, suite string) (bool, error) {
+	return it.re.MatchString(suite), nil
+}
+
+// tagPattern matches suites having at least one test with a matching tag.
+// Tags are compared like Robot Framework does, ignoring case, spaces and underscores.
+type tagPattern struct {
+	re *regexp.Regexp
+}
+
+func normalizeTag(tag string) string {
+	return strings.NewReplacer(" ", "", "_", "").Replace(strings.ToLower(tag))
+}
+
+func (it *tagPattern) Match(root string, suite string) (bool, error) {
+	suiteFile, err := ParseSuiteFile(filepath.Join(root, filepath.FromSlash(suite)))
+	if err != nil {
+		return false, err
+	}
+
+	for _, test := range suiteFile.Tests {
+		for _, tag := range test.Tags {
+			if it.re.MatchString(normalizeTag(tag)) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func newTagPattern(tag string) (*tagPattern, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, c := range normalizeTag(tag) {
+		switch c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag pattern %q: %s", tag, err)
+	}
+
+	return &tagPattern{re: re}, nil
+}
+
+func newPattern(expression string) (pattern, error) {
+	switch {
+	case strings.HasPrefix(expression, regexPrefix):
+		re, err := regexp.Compile(strings.TrimPrefix(expression, regexPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %s", expression, err)
+		}
+		return &regexPattern{re: re}, nil
+	case strings.HasPrefix(expression, tagPrefix):
+		return newTagPattern(strings.TrimPrefix(expression, tagPrefix))
+	default:
+		return newGlobPattern(strings.TrimPrefix(path.Clean(filepath.ToSlash(expression)), "./"))
+	}
+}
+
+// Selector filters workspace suites by include and exclude patterns.
+// A suite is selected when it matches any of the includes (or there are none)
+// and none of the excludes.
+//
+// Patterns are globs relative to the workspace root (e.g. tasks/*, **/smoke_*.robot),
+// regular expressions when prefixed with "re:" and Robot Framework tags when prefixed with "tag:".
+type Selector struct {
+	includes []pattern
+	excludes []pattern
+}
+
+func matchAny(patterns []pattern, root string, suite string) (bool, error) {
+	for _, p := range patterns {
+		matched, err := p.Match(root, suite)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Match tells whether the suite, a path relative to the given workspace root, is selected
+func (it *Selector) Match(root string, suite string) (bool, error) {
+	if len(it.includes) > 0 {
+		included, err := matchAny(it.includes, root, suite)
+		if err != nil || !included {
+			return false, err
+		}
+	}
+
+	excluded, err := matchAny(it.excludes, root, suite)
+	if err != nil {
+		return false, err
+	}
+
+	return !excluded, nil
+}
+
+// Select returns the suites of the workspace matching the selector
+func (it *Selector) Select(w *Workspace) ([]string, error) {
+	root := w.Root()
+
+	selected := make([]string, 0)
+	for _, suite := range w.Suites() {
+		matched, err := it.Match(root.Path, suite)
+		if err != nil {
+			return nil, fmt.Errorf("select %s: %s", suite, err)
+		}
+		if matched {
+			selected = append(selected, suite)
+		}
+	}
+
+	return selected, nil
+}
+
+func NewSelector(includes []string, excludes []string) (*Selector, error) {
+	s := Selector{}
+
+	for _, expression := range includes {
+		p, err := newPattern(expression)
+		if err != nil {
+			return nil, err
+		}
+		s.includes = append(s.includes, p)
+	}
+
+	for _, expression := range excludes {
+		p, err := newPattern(expression)
+		if err != nil {
+			return nil, err
+		}
+		s.excludes = append(s.excludes, p)
+	}
+
+	return &s, nil
+}
@@ -0,0 +1,138 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func createWorkspace(t *testing.T, files map[string]string) *Workspace {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+func TestWorkspace_Suites(t *testing.T) {
+	w := createWorkspace(t, map[string]string{
+		"home.robot":                "",
+		"README.md":                 "",
+		"checkout/__init__.robot":   "",
+		"checkout/cart.robot":       "",
+		"checkout/common.resource":  "",
+		"admin/users/create.robot":  "",
+		"admin/users/delete.robot":  "",
+		"search/filters/.gitignore": "",
+		".venv/lib/site.robot":      "",
+		".kubot/venv/example.robot": "",
+		"_resources/keywords.robot": "",
+		"admin/_draft.robot":        "",
+		"admin/.hidden.robot":       "",
+	})
+
+	want := []string{"home.robot", "admin/users/create.robot", "admin/users/delete.robot", "checkout/cart.robot"}
+	if got := w.Suites(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Suites() got = %v, want %v", got, want)
+	}
+}
+
+func TestParseSuiteFile(t *testing.T) {
+	w := createWorkspace(t, map[string]string{
+		"login.robot": `*** Settings ***
+Force Tags      ui    login
+Default Tags    regression
+...             nightly
+
+*** Test Cases ***
+Valid Login
+    [Tags]    smoke    critical
+    Log    ok
+
+Invalid Login
+    Log    ok    # [Tags]    ignored
+
+Untagged Login    [Tags]    NONE
+    Log    ok
+`,
+	})
+
+	suite, err := ParseSuiteFile(filepath.Join(w.Root().Path, "login.robot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []TestCase{
+		{Name: "Valid Login", Tags: []string{"ui", "login", "smoke", "critical"}},
+		{Name: "Invalid Login", Tags: []string{"ui", "login", "regression", "nightly"}},
+		{Name: "Untagged Login", Tags: []string{"ui", "login"}},
+	}
+	if !reflect.DeepEqual(suite.Tests, want) {
+		t.Errorf("ParseSuiteFile() tests = %v, want %v", suite.Tests, want)
+	}
+}
+
+func TestSelector_Select(t *testing.T) {
+	w := createWorkspace(t, map[string]string{
+		"smoke_login.robot":        "*** Test Cases ***\nLogin\n    [Tags]    Smoke_Test\n    Log    ok\n",
+		"smoke_search.robot":       "*** Test Cases ***\nSearch\n    Log    ok\n",
+		"checkout.robot":           "*** Settings ***\nForce Tags    payment\n\n*** Test Cases ***\nPay\n    Log    ok\n",
+		"admin/users/create.robot": "*** Test Cases ***\nCreate User\n    Log    ok\n",
+	})
+
+	tests := []struct {
+		name     string
+		includes []string
+		excludes []string
+		want     []string
+	}{
+		{name: "no selectors", want: []string{"checkout.robot", "smoke_login.robot", "smoke_search.robot", "admin/users/create.robot"}},
+		{name: "glob", includes: []string{"smoke_*"}, want: []string{"smoke_login.robot", "smoke_search.robot"}},
+		{name: "double star glob", includes: []string{"**/smoke_*.robot"}, want: []string{"smoke_login.robot", "smoke_search.robot"}},
+		{name: "regex", includes: []string{"re:^(checkout|smoke_search)"}, want: []string{"checkout.robot", "smoke_search.robot"}},
+		{name: "tag", includes: []string{"tag:smoke test"}, want: []string{"smoke_login.robot"}},
+		{name: "force tag wildcard", includes: []string{"tag:pay*"}, want: []string{"checkout.robot"}},
+		{name: "multiple includes", includes: []string{"checkout.robot", "tag:smoketest"}, want: []string{"checkout.robot", "smoke_login.robot"}},
+		{name: "exclude", includes: []string{"smoke_*"}, excludes: []string{"*search*"}, want: []string{"smoke_login.robot"}},
+		{name: "exclude only", excludes: []string{"tag:payment"}, want: []string{"smoke_login.robot", "smoke_search.robot", "admin/users/create.robot"}},
+		{name: "directory", includes: []string{"admin"}, want: []string{"admin/users/create.robot"}},
+		{name: "nested double star glob", includes: []string{"**/create.robot"}, want: []string{"admin/users/create.robot"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSelector(tt.includes, tt.excludes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.Select(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSelector_InvalidPattern(t *testing.T) {
+	if _, err := NewSelector([]string{"re:("}, nil); err == nil {
+		t.Errorf("NewSelector() expected error for invalid regex")
+	}
+	if _, err := NewSelector(nil, []string{"tasks/[a-"}); err == nil {
+		t.Errorf("NewSelector() expected error for invalid glob")
+	}
+}